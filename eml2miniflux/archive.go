@@ -0,0 +1,299 @@
+package eml2miniflux
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/sg3des/eml"
+	"miniflux.app/model"
+)
+
+// Archive formats by file name suffix
+var archiveSuffixes = []struct {
+	suffix string
+	isZip  bool
+	open   func(r io.Reader) (io.ReadCloser, error)
+}{
+	{suffix: ".zip", isZip: true},
+	{suffix: ".tar", open: openPlainStream},
+	{suffix: ".tar.gz", open: openGzipStream},
+	{suffix: ".tgz", open: openGzipStream},
+	{suffix: ".tar.zst", open: openZstdStream},
+	{suffix: ".tzst", open: openZstdStream},
+}
+
+// Compressions of streams by their magic bytes
+var streamCompressions = []struct {
+	magic []byte
+	open  func(r io.Reader) (io.ReadCloser, error)
+}{
+	{magic: []byte{0x1f, 0x8b}, open: openGzipStream},
+	{magic: []byte{0x28, 0xb5, 0x2f, 0xfd}, open: openZstdStream},
+}
+
+// Formats of tar archive streams by their magic bytes at the offset
+var archiveStreamMagics = []struct {
+	magic  []byte
+	offset int
+	open   func(r io.Reader) (io.ReadCloser, error)
+}{
+	{magic: []byte{0x1f, 0x8b}, open: openGzipStream},
+	{magic: []byte{0x28, 0xb5, 0x2f, 0xfd}, open: openZstdStream},
+	{magic: []byte("ustar"), offset: 257, open: openPlainStream},
+}
+
+// Bytes of a stream enough to detect its archive format
+const archiveStreamHeaderSize = 262
+
+func openPlainStream(r io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(r), nil
+}
+
+func openGzipStream(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+func openZstdStream(r io.Reader) (io.ReadCloser, error) {
+	decoder, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return decoder.IOReadCloser(), nil
+}
+
+// IsArchiveFile determines if a file represented by `filePath`
+// is a zip or tar archive (optionally compressed by gzip or zstd) by its name
+func IsArchiveFile(filePath string) bool {
+	name := strings.ToLower(filePath)
+	for _, format := range archiveSuffixes {
+		if strings.HasSuffix(name, format.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsArchiveStream determines if a stream starting with `header` is a tar archive,
+// optionally compressed by gzip or zstd
+func IsArchiveStream(header []byte) bool {
+	return archiveStreamOpener(header) != nil
+}
+
+// Decompressor of tar archive stream starting with `header`; nil when the stream is not an archive
+func archiveStreamOpener(header []byte) func(r io.Reader) (io.ReadCloser, error) {
+	for _, format := range archiveStreamMagics {
+		if len(header) >= format.offset+len(format.magic) && bytes.Equal(header[format.offset:format.offset+len(format.magic)], format.magic) {
+			return format.open
+		}
+	}
+	return nil
+}
+
+// DecompressStream returns the content of stream `r` when it is compressed by gzip or zstd,
+// detected by the magic bytes; otherwise `r` itself. A tar archive is decompressed as well.
+func DecompressStream(r *bufio.Reader) (*bufio.Reader, error) {
+	header, err := r.Peek(4)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	for _, format := range streamCompressions {
+		if bytes.HasPrefix(header, format.magic) {
+			stream, err := format.open(r)
+			if err != nil {
+				return nil, fmt.Errorf("cannot decompress stream: %s", err)
+			}
+			return bufio.NewReader(stream), nil
+		}
+	}
+
+	return r, nil
+}
+
+// Create entries for the archive member, which is either EML file, Outlook message or mbox; other members are skipped
+func (c *entryCollector) addArchiveMember(archivePath string, name string, r io.Reader) error {
+	source := archivePath + ": " + name
+	// Folder of the member is given relative to the archive, as it would be after extracting
+	folder := filepath.Join(archivePath, filepath.FromSlash(path.Dir(name)))
+
+	if strings.HasSuffix(strings.ToLower(name), ".eml") {
+		c.countMessage()
+
+		raw, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("cannot read archive member: %s: %s", source, err)
+		}
+
+		message, err := eml.Parse(raw)
+		if err != nil {
+			reportEntryError(source, fmt.Errorf("cannot parse EML: %s", err), c.config.Quiet)
+			return nil
+		}
+
+		c.addMessage(source, &message, &MessageInfo{Folder: folder})
+		return nil
+	}
+
+	if strings.HasSuffix(strings.ToLower(name), ".msg") {
+		c.countMessage()
+
+		raw, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("cannot read archive member: %s: %s", source, err)
+		}
+
+		message, info, err := parseMsg(bytes.NewReader(raw), folder)
+		if err != nil {
+			reportEntryError(source, err, c.config.Quiet)
+			return nil
+		}
+
+		c.addMessage(source, message, info)
+		return nil
+	}
+
+	// Thunderbird folder files have no extension, so detect them by content
+	reader := bufio.NewReader(r)
+	header, err := reader.Peek(len(mboxFromLine))
+	if err != nil || !bytes.Equal(header, mboxFromLine) {
+		return nil
+	}
+
+	err = c.addMboxStream(source, &MessageInfo{Folder: filepath.Join(archivePath, filepath.FromSlash(name))}, reader)
+	if err != nil {
+		return fmt.Errorf("cannot read mbox: %s: %s", source, err)
+	}
+
+	return nil
+}
+
+// Create entries for members of zip archive
+func (c *entryCollector) addZip(archivePath string) error {
+	archive, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("cannot open archive: %s", err)
+	}
+	defer archive.Close()
+
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		member, err := file.Open()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error on processing file: %s: %s: %s\n", archivePath, file.Name, err)
+			continue
+		}
+
+		err = c.addArchiveMember(archivePath, file.Name, member)
+		member.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error on processing file: %s\n", err)
+		}
+	}
+
+	return nil
+}
+
+// Create entries for members of tar archive; the archive is read as a stream
+func (c *entryCollector) addTar(archivePath string, open func(r io.Reader) (io.ReadCloser, error)) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("cannot open archive: %s", err)
+	}
+	defer file.Close()
+
+	return c.addTarStream(archivePath, bufio.NewReader(file), open)
+}
+
+// Create entries for members of tar archive read from `r`; `archivePath` names the archive
+func (c *entryCollector) addTarStream(archivePath string, r io.Reader, open func(r io.Reader) (io.ReadCloser, error)) error {
+	stream, err := open(r)
+	if err != nil {
+		return fmt.Errorf("cannot decompress archive: %s", err)
+	}
+	defer stream.Close()
+
+	archive := tar.NewReader(stream)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("cannot read archive: %s", err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		err = c.addArchiveMember(archivePath, header.Name, archive)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error on processing file: %s\n", err)
+		}
+	}
+}
+
+// Create entries for EML and mbox members of the archive
+func (c *entryCollector) addArchive(archivePath string) error {
+	name := strings.ToLower(archivePath)
+
+	for _, format := range archiveSuffixes {
+		if !strings.HasSuffix(name, format.suffix) {
+			continue
+		}
+
+		if format.isZip {
+			return c.addZip(archivePath)
+		}
+		return c.addTar(archivePath, format.open)
+	}
+
+	return fmt.Errorf("unknown archive format: %s", archivePath)
+}
+
+// Load messages from zip, tar, tar.gz or tar.zst archive specified by archivePath
+// and create model Entry for each of them. The members are read without extracting them.
+func GetEntriesForArchive(config *EntryConfig, archivePath string) (model.Entries, error) {
+	c := newEntryCollector(config)
+	c.root = archivePath
+
+	err := c.addArchive(archivePath)
+	fmt.Fprintf(os.Stdout, "Reading archive completed. Processed messages: %d\n", c.entryCounter)
+
+	return c.entries, err
+}
+
+// Load messages from tar, tar.gz or tar.zst archive read from `r` and create model Entry for each of them;
+// the format is detected by the content. `source` names the reader in error messages.
+func GetEntriesForArchiveReader(config *EntryConfig, source string, r io.Reader) (model.Entries, error) {
+	c := newEntryCollector(config)
+	c.root = source
+
+	reader := bufio.NewReader(r)
+	header, err := reader.Peek(archiveStreamHeaderSize)
+	if err != nil && err != io.EOF {
+		return c.entries, fmt.Errorf("cannot read %s: %s", source, err)
+	}
+
+	open := archiveStreamOpener(header)
+	if open == nil {
+		return c.entries, fmt.Errorf("unknown archive format: %s", source)
+	}
+
+	err = c.addTarStream(source, reader, open)
+	fmt.Fprintf(os.Stdout, "Reading archive completed. Processed messages: %d\n", c.entryCounter)
+
+	return c.entries, err
+}
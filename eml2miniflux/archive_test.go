@@ -0,0 +1,85 @@
+package eml2miniflux
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"miniflux.app/model"
+)
+
+// Tar archive holding the files by name
+func testTarArchive(t *testing.T, files map[string][]byte) []byte {
+	var buf bytes.Buffer
+	archive := tar.NewWriter(&buf)
+	for name, data := range files {
+		if err := archive.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := archive.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGetEntriesForArchiveReader(t *testing.T) {
+	archive := testTarArchive(t, map[string][]byte{
+		"blog/first.eml": testFeedMessage("First", "https://example.com/first"),
+		"notes.txt":      []byte("not a message"),
+	})
+
+	var gzipped bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipped)
+	gzipWriter.Write(archive)
+	gzipWriter.Close()
+
+	var zstdCompressed bytes.Buffer
+	zstdWriter, err := zstd.NewWriter(&zstdCompressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zstdWriter.Write(archive)
+	zstdWriter.Close()
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr bool
+	}{
+		{name: "tar", data: archive},
+		{name: "tar.gz", data: gzipped.Bytes()},
+		{name: "tar.zst", data: zstdCompressed.Bytes()},
+		{name: "message", data: testFeedMessage("First", "https://example.com/first"), wantErr: true},
+	}
+
+	feed := &model.Feed{FeedURL: "https://example.com/feed.xml"}
+	config := &EntryConfig{FeedHelper: newTestFeedHelper(t, "", feed), User: &model.User{ID: 1}, DefaultFeed: feed}
+
+	for _, test := range tests {
+		if got := IsArchiveStream(test.data); got == test.wantErr {
+			t.Errorf("%s: IsArchiveStream() = %v", test.name, got)
+		}
+
+		entries, err := GetEntriesForArchiveReader(config, test.name, bytes.NewReader(test.data))
+		if test.wantErr {
+			if err == nil || !strings.Contains(err.Error(), "unknown archive format") {
+				t.Errorf("%s: got error %v, want unknown archive format", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		if len(entries) != 1 || entries[0].URL != "https://example.com/first" {
+			t.Errorf("%s: got %d entries", test.name, len(entries))
+		}
+	}
+}
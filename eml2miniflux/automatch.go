@@ -0,0 +1,184 @@
+package eml2miniflux
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"miniflux.app/model"
+)
+
+// Site of the feed: host without `www.` prefix and path without trailing slash
+type autoMatchSite struct {
+	host string
+	path string
+	feed *model.Feed
+}
+
+// Entries matched to the same site, written as a rule of the feed map; ambiguous when several feeds have the site
+type autoMatchRule struct {
+	host     string
+	path     string
+	feedUrls []string
+	entries  int
+	sample   string
+}
+
+// Matches entry URLs to the feeds by their site URLs when the feed map has no rule for them
+type autoMatcher struct {
+	sites []autoMatchSite
+	rules map[string]*autoMatchRule
+}
+
+// Host and path of the URL, ignoring scheme and `www.` prefix
+func siteHostPath(rawUrl string) (string, string) {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return "", ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www."), strings.TrimSuffix(u.Path, "/")
+}
+
+// EnableAutoMatch makes entries unmatched by the feed map go to the feed whose site URL
+// has the host of the entry URL and shares the longest path prefix with it.
+// Hosts are compared exactly, except `www.` prefix: a subdomain is another site.
+func (h *FeedHelper) EnableAutoMatch() {
+	matcher := autoMatcher{rules: make(map[string]*autoMatchRule)}
+
+	for _, feed := range h.feedsId {
+		host, path := siteHostPath(feed.SiteURL)
+		if len(host) == 0 {
+			// without site URL the host of the feed URL stands for the site; its path is of the feed file
+			host, _ = siteHostPath(feed.FeedURL)
+			path = ""
+		}
+		if len(host) > 0 {
+			matcher.sites = append(matcher.sites, autoMatchSite{host: host, path: path, feed: feed})
+		}
+	}
+
+	h.autoMatcher = &matcher
+}
+
+// Feed of the entry by site URL; nil when no site matches, or the sites of several feeds match equally
+func (h *FeedHelper) autoMatchFeed(entryUrl string) *model.Feed {
+	if h.autoMatcher == nil {
+		return nil
+	}
+
+	host, path := siteHostPath(entryUrl)
+	if len(host) == 0 {
+		return nil
+	}
+
+	var best []autoMatchSite
+	for _, site := range h.autoMatcher.sites {
+		if site.host != host || !(path == site.path || strings.HasPrefix(path, site.path+"/")) {
+			continue
+		}
+		if len(best) > 0 && len(site.path) < len(best[0].path) {
+			continue
+		}
+		if len(best) > 0 && len(site.path) > len(best[0].path) {
+			best = nil
+		}
+		best = append(best, site)
+	}
+
+	if len(best) == 0 {
+		return nil
+	}
+
+	feeds := make(map[int64]*model.Feed)
+	for _, site := range best {
+		feeds[site.feed.ID] = site.feed
+	}
+
+	key := best[0].host + best[0].path
+	rule, ok := h.autoMatcher.rules[key]
+	if !ok {
+		rule = &autoMatchRule{host: best[0].host, path: best[0].path, sample: entryUrl}
+		for _, feed := range feeds {
+			rule.feedUrls = append(rule.feedUrls, feed.FeedURL)
+		}
+		sort.Strings(rule.feedUrls)
+		h.autoMatcher.rules[key] = rule
+	}
+	rule.entries++
+
+	if len(feeds) > 1 {
+		return nil
+	}
+	return best[0].feed
+}
+
+// Pattern of the feed map matching the site on any port; anchored to the host, as `host:` would match its subdomains too
+func (r *autoMatchRule) pattern() string {
+	return `re:^https?://(www\.)?` + regexp.QuoteMeta(r.host) + `(:\d+)?` + regexp.QuoteMeta(r.path) + `(/|$)`
+}
+
+// Rules of the matched sites, by pattern
+func (h *FeedHelper) autoMatchRules() []*autoMatchRule {
+	var rules []*autoMatchRule
+	if h.autoMatcher != nil {
+		for _, rule := range h.autoMatcher.rules {
+			rules = append(rules, rule)
+		}
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].pattern() < rules[j].pattern()
+	})
+
+	return rules
+}
+
+// AutoMatchAmbiguous returns entries whose site URL is shared by several feeds, one per site;
+// the rules list the candidate feeds
+func (h *FeedHelper) AutoMatchAmbiguous() []AmbiguousMatch {
+	var matches []AmbiguousMatch
+	for _, rule := range h.autoMatchRules() {
+		if len(rule.feedUrls) < 2 {
+			continue
+		}
+
+		match := AmbiguousMatch{EntryUrl: rule.sample}
+		for _, feedUrl := range rule.feedUrls {
+			match.Rules = append(match.Rules, fmt.Sprintf("%s => %s", rule.pattern(), feedUrl))
+		}
+		matches = append(matches, match)
+	}
+	return matches
+}
+
+// WriteAutoMatchMap writes the feed map with a rule for each site matched by the entries.
+// Rules of the sites shared by several feeds are commented out. Returns amount of the entries matched.
+func (h *FeedHelper) WriteAutoMatchMap(fileName string) (int, error) {
+	var feedMap strings.Builder
+	feedMap.WriteString("# Feed map matching the entries by site URLs of the feeds; review it before using with '-feedmap'\n")
+
+	matched := 0
+	for _, rule := range h.autoMatchRules() {
+		fmt.Fprintf(&feedMap, "\n# Entries: %d, ex.: %s\n", rule.entries, rule.sample)
+		if len(rule.feedUrls) > 1 {
+			fmt.Fprintf(&feedMap, "# Several feeds have this site:\n")
+			for _, feedUrl := range rule.feedUrls {
+				fmt.Fprintf(&feedMap, "# %s => %s\n", rule.pattern(), feedUrl)
+			}
+			continue
+		}
+
+		matched += rule.entries
+		fmt.Fprintf(&feedMap, "%s => %s\n", rule.pattern(), rule.feedUrls[0])
+	}
+
+	err := os.WriteFile(fileName, []byte(feedMap.String()), 0666)
+	if err != nil {
+		return matched, fmt.Errorf("cannot write feed map file: %s", err)
+	}
+
+	return matched, nil
+}
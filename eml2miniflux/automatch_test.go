@@ -0,0 +1,86 @@
+package eml2miniflux
+
+import (
+	"testing"
+
+	"miniflux.app/model"
+)
+
+func TestAutoMatchRulePattern(t *testing.T) {
+	tests := []struct {
+		siteUrl  string
+		entryUrl string
+		pattern  string
+		matches  []string
+		others   []string
+	}{
+		{
+			siteUrl:  "https://example.com/",
+			entryUrl: "https://www.example.com/post",
+			pattern:  `re:^https?://(www\.)?example\.com(:\d+)?(/|$)`,
+			matches:  []string{"https://example.com/post", "http://www.example.com", "https://example.com/", "https://example.com:8443/post"},
+			others:   []string{"https://blog.example.com/post", "https://example.com.evil.org/post", "https://notexample.com/"},
+		},
+		{
+			siteUrl:  "https://example.org/blog/",
+			entryUrl: "https://example.org/blog/post",
+			pattern:  `re:^https?://(www\.)?example\.org(:\d+)?/blog(/|$)`,
+			matches:  []string{"https://example.org/blog/post", "https://www.example.org/blog", "http://example.org:8080/blog/post"},
+			others:   []string{"https://example.org/blogroll", "https://cdn.example.org/blog/post", "https://example.org:8080/other"},
+		},
+	}
+
+	for _, test := range tests {
+		feed := &model.Feed{SiteURL: test.siteUrl, FeedURL: test.siteUrl + "feed.xml"}
+		feedHelper := newTestFeedHelper(t, "", feed)
+		feedHelper.EnableAutoMatch()
+
+		if matched := feedHelper.autoMatchFeed(test.entryUrl); matched != feed {
+			t.Errorf("%s: entry %s is not matched", test.siteUrl, test.entryUrl)
+			continue
+		}
+
+		rules := feedHelper.autoMatchRules()
+		if len(rules) != 1 || rules[0].pattern() != test.pattern {
+			t.Errorf("%s: got rules %v, want pattern %s", test.siteUrl, rules, test.pattern)
+			continue
+		}
+
+		// The written rule is applied by the feed map to the site only
+		rule, err := parseFeedRule(rules[0].pattern(), feed.FeedURL, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, entryUrl := range test.matches {
+			if _, ok := rule.match(entryUrl); !ok {
+				t.Errorf("%s does not match %s", test.pattern, entryUrl)
+			}
+		}
+		for _, entryUrl := range test.others {
+			if _, ok := rule.match(entryUrl); ok {
+				t.Errorf("%s matches %s", test.pattern, entryUrl)
+			}
+		}
+	}
+}
+
+func TestAutoMatchFeedExactHost(t *testing.T) {
+	feed := &model.Feed{SiteURL: "https://example.com/", FeedURL: "https://example.com/feed.xml"}
+	feedHelper := newTestFeedHelper(t, "", feed)
+	feedHelper.EnableAutoMatch()
+
+	tests := []struct {
+		entryUrl string
+		want     *model.Feed
+	}{
+		{"https://www.example.com/post", feed},
+		{"https://example.com:8443/post", feed},
+		{"https://blog.example.com/post", nil},
+	}
+
+	for _, test := range tests {
+		if got := feedHelper.autoMatchFeed(test.entryUrl); got != test.want {
+			t.Errorf("autoMatchFeed(%s) = %v, want %v", test.entryUrl, got, test.want)
+		}
+	}
+}
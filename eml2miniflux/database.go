@@ -18,6 +18,11 @@ type DatabaseProcessor struct {
 	Retries   int
 }
 
+type entryState struct {
+	read    bool
+	starred bool
+}
+
 type databaseProcessorFunc func(entries model.Entries) error
 
 func (p *DatabaseProcessor) databaseProcessorRun(proc databaseProcessorFunc, allEntries model.Entries) error {
@@ -55,6 +60,16 @@ func (p *DatabaseProcessor) databaseProcessorRun(proc databaseProcessorFunc, all
 }
 
 func (p *DatabaseProcessor) UpdateStorageEntries(allEntries model.Entries, overwrite bool) error {
+	// Storage creates entries as unread and not starred, and overwrites the status field,
+	// so the requested state is kept aside to be applied after insertion
+	entryStates := make(map[*model.Entry]entryState, len(allEntries))
+	for _, entry := range allEntries {
+		entryStates[entry] = entryState{
+			read:    entry.Status == model.EntryStatusRead,
+			starred: entry.Starred,
+		}
+	}
+
 	proc := func(batch model.Entries) error {
 		if len(batch) == 0 {
 			return nil
@@ -83,12 +98,49 @@ func (p *DatabaseProcessor) UpdateStorageEntries(allEntries model.Entries, overw
 			}
 		}
 
-		return nil
+		return p.applyEntryStates(userID, batch, entryStates)
 	}
 
 	return p.databaseProcessorRun(proc, allEntries)
 }
 
+// Set read status and star for inserted or updated entries
+func (p *DatabaseProcessor) applyEntryStates(userID int64, batch model.Entries, entryStates map[*model.Entry]entryState) error {
+	var readIDs, starredIDs []int64
+
+	for _, entry := range batch {
+		if entry.ID == 0 {
+			// entry already existed and was not updated
+			continue
+		}
+
+		state := entryStates[entry]
+		if state.read {
+			readIDs = append(readIDs, entry.ID)
+			entry.Status = model.EntryStatusRead
+		}
+		if state.starred {
+			starredIDs = append(starredIDs, entry.ID)
+		}
+	}
+
+	if len(readIDs) > 0 {
+		err := p.Store.SetEntriesStatus(userID, readIDs, model.EntryStatusRead)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(starredIDs) > 0 {
+		err := p.Store.SetEntriesBookmarkedState(userID, starredIDs, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (p *DatabaseProcessor) RemoveStorageEntries(allEntries model.Entries) error {
 	proc := func(batch model.Entries) error {
 		if len(batch) == 0 {
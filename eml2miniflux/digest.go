@@ -0,0 +1,412 @@
+package eml2miniflux
+
+import (
+	"bufio"
+	"fmt"
+	"mime"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/sg3des/eml"
+	"miniflux.app/crypto"
+	"miniflux.app/model"
+	"miniflux.app/reader/date"
+	"miniflux.app/url"
+)
+
+const (
+	// Prefix of a built-in template in the digest rules file
+	digestTemplatePrefix = "template:"
+
+	// Headings which may title an item; only headings holding a link are taken
+	digestHeadings = "h1, h2, h3, h4"
+)
+
+// Rule to split a digest into items: either each element matching `items` is an item,
+// or an item spans from a linked heading matching `headings` to the next one
+type digestRule struct {
+	items    string
+	headings string
+	// Element of the item holding its title link; a linked heading, or the first link when empty
+	title string
+	// Element of the item holding its date; `time` element, or a date within the text when empty
+	date string
+	// Hosts of the service sending the digest; the blocks linking to them, ex. to manage
+	// the subscription, are not items
+	serviceHosts []string
+}
+
+// Built-in templates of digest services, and `headings` for any letter titling each item with a linked heading
+var digestTemplates = map[string]*digestRule{
+	// Blogtrottr titles each item with a linked `h2`, followed by its content; its footer links to blogtrottr.com
+	"blogtrottr": {headings: "h1, h2, h3", serviceHosts: []string{"blogtrottr.com"}},
+	// FeedBurner puts each item into `.regularitem` block, with `.itemtitle` heading and `.itemposttime` date
+	"feedburner": {
+		items:        ".regularitem",
+		title:        ".itemtitle",
+		date:         ".itemposttime",
+		serviceHosts: []string{"feedburner.com", "feedburner.google.com"},
+	},
+	"headings": {headings: digestHeadings},
+	// IFTTT digests title each item with a bold link rather than a heading; its footer links to ifttt.com
+	"ifttt": {headings: "h1, h2, h3, h4, b, strong", serviceHosts: []string{"ifttt.com"}},
+}
+
+var (
+	// Date within the text of a digest item, ex.: `2023-01-02`, `2023-01-02 15:04`, `Jan 2, 2023`, `2 January 2023`
+	// The month name may follow other text without a space, as the text of elements is joined
+	digestDateRx = regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}([T ]\d{2}:\d{2}(:\d{2})?(Z|[+-]\d{2}:?\d{2})?)?|` +
+		`(Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec)[a-z]* \d{1,2}, \d{4}|` +
+		`\b\d{1,2} (Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec)[a-z]* \d{4}`)
+
+	// Label preceding the date, ex.: `Posted:`
+	digestDateLabelRx = regexp.MustCompile(`^\s*[A-Za-z ]+:\s*`)
+)
+
+// Item cut from a digest
+type digestItem struct {
+	title   string
+	url     string
+	content string
+	date    time.Time
+}
+
+// LoadDigestRules loads rules which split the digests of feeds. Each line holds the feed URL,
+// `=>` separator, and either CSS selector of the items or a built-in template, ex.:
+// `https://example.com/feed.xml => template:headings`.
+func (h *FeedHelper) LoadDigestRules(fileName string) error {
+	h.digestRules = make(map[int64]*digestRule)
+
+	file, err := os.Open(fileName)
+	if err != nil {
+		return fmt.Errorf("cannot open digest rules file: %s", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNum := 1
+	for scanner.Scan() {
+		line := scanner.Text()
+		err = h.processDigestLine(line)
+		if err != nil {
+			return fmt.Errorf(`wrong digest rule line #%d: %s: %s`, lineNum, err, line)
+		}
+		lineNum++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("cannot read digest rules file: %s", err)
+	}
+
+	return nil
+}
+
+func (h *FeedHelper) processDigestLine(line string) error {
+	line = strings.TrimSpace(line)
+
+	if len(line) == 0 || strings.HasPrefix(line, `#`) {
+		return nil
+	}
+
+	parts := strings.Split(line, `=>`)
+	if len(parts) != 2 {
+		return fmt.Errorf(`separator => is missing`)
+	}
+
+	feedUrl := strings.TrimSpace(parts[0])
+	feed := h.feedsUrl[feedUrl]
+	if feed == nil {
+		return fmt.Errorf(`cannot find feed with URL: %s`, feedUrl)
+	}
+
+	selector := strings.TrimSpace(parts[1])
+	if len(selector) == 0 {
+		return fmt.Errorf(`selector is missing`)
+	}
+
+	if strings.HasPrefix(selector, digestTemplatePrefix) {
+		name := strings.TrimSpace(strings.TrimPrefix(selector, digestTemplatePrefix))
+		rule, ok := digestTemplates[strings.ToLower(name)]
+		if !ok {
+			return fmt.Errorf(`unknown template: %s`, name)
+		}
+		h.digestRules[feed.ID] = rule
+		return nil
+	}
+
+	// Reject a malformed selector now, as goquery would match nothing by it
+	if _, err := cascadia.Compile(selector); err != nil {
+		return fmt.Errorf(`wrong selector: %s`, err)
+	}
+
+	h.digestRules[feed.ID] = &digestRule{items: selector}
+	return nil
+}
+
+// Rule splitting the digests of the feed, nil when the feed has none
+func (h *FeedHelper) digestRuleForFeed(feed *model.Feed) *digestRule {
+	if h == nil || feed == nil {
+		return nil
+	}
+	return h.digestRules[feed.ID]
+}
+
+// Determine if the message is `multipart/digest`, i.e. its parts are messages
+func isDigestMessage(message *eml.Message) bool {
+	mediaType, _, err := mime.ParseMediaType(messageHeader(message, "Content-Type"))
+	return err == nil && mediaType == "multipart/digest"
+}
+
+// Messages attached to the message as `message/rfc822` parts; parts of `multipart/digest` are messages by default
+func attachedMessages(message *eml.Message) ([]*eml.Message, error) {
+	isDigest := isDigestMessage(message)
+
+	var messages []*eml.Message
+	for _, part := range message.Parts {
+		mediaType, _, _ := mime.ParseMediaType(part.Type)
+		if mediaType != "message/rfc822" && !(isDigest && len(part.Type) == 0) {
+			continue
+		}
+
+		attached, err := eml.Parse(part.Data)
+		if err != nil {
+			return messages, fmt.Errorf("cannot parse attached message #%d: %s", len(messages)+1, err)
+		}
+		messages = append(messages, &attached)
+	}
+
+	return messages, nil
+}
+
+// Determine if the element titles a digest item
+func isDigestHeading(s *goquery.Selection, headings string) bool {
+	return s.Is(headings) && s.Find("a[href]").Length() > 0
+}
+
+// Number of item headings in the element, including the element itself
+func countDigestHeadings(s *goquery.Selection, headings string) int {
+	count := s.Find(headings).FilterFunction(func(_ int, heading *goquery.Selection) bool {
+		return isDigestHeading(heading, headings)
+	}).Length()
+	if isDigestHeading(s, headings) {
+		count++
+	}
+	return count
+}
+
+// Cut items of the digest at linked headings. The item is the largest block holding just its heading,
+// together with the following blocks up to the next heading.
+func digestItemsByHeadings(doc *goquery.Document, headings string) []*goquery.Selection {
+	var items []*goquery.Selection
+
+	doc.Find(headings).Each(func(_ int, heading *goquery.Selection) {
+		if !isDigestHeading(heading, headings) {
+			return
+		}
+
+		start := heading
+		for parent := heading.Parent(); parent.Length() > 0 && !parent.Is("body"); parent = parent.Parent() {
+			if countDigestHeadings(parent, headings) > 1 {
+				break
+			}
+			start = parent
+		}
+
+		content, _ := goquery.OuterHtml(start)
+
+		following := false
+		start.Parent().Contents().EachWithBreak(func(_ int, node *goquery.Selection) bool {
+			if node.Nodes[0] == start.Nodes[0] {
+				following = true
+				return true
+			}
+			if !following {
+				return true
+			}
+			if countDigestHeadings(node, headings) > 0 {
+				return false
+			}
+			html, _ := goquery.OuterHtml(node)
+			content += html
+			return true
+		})
+
+		// Wrap the item into a new element, so it is handled as the items found by selector
+		itemDoc, err := goquery.NewDocumentFromReader(strings.NewReader("<div>" + content + "</div>"))
+		if err != nil {
+			return
+		}
+		items = append(items, itemDoc.Find("body > div").First())
+	})
+
+	return items
+}
+
+// Date of the digest item: text of the date element of the rule, `datetime` attribute or text of `time` element,
+// otherwise the first date within the text of the item; zero when the item has none
+func digestItemDate(item *goquery.Selection, rule *digestRule) time.Time {
+	var values []string
+
+	if len(rule.date) > 0 {
+		text := item.Find(rule.date).First().Text()
+		values = append(values, digestDateLabelRx.ReplaceAllString(text, ""), digestDateRx.FindString(text))
+	}
+	item.Find("[datetime]").Each(func(_ int, s *goquery.Selection) {
+		datetime, _ := s.Attr("datetime")
+		values = append(values, datetime)
+	})
+	item.Find("time").Each(func(_ int, s *goquery.Selection) {
+		values = append(values, s.Text())
+	})
+	values = append(values, digestDateRx.FindString(item.Text()))
+
+	for _, value := range values {
+		if d, err := date.Parse(strings.TrimSpace(value)); err == nil {
+			return d
+		}
+	}
+
+	return time.Time{}
+}
+
+// Determine if the link refers to the service sending the digest
+func isDigestServiceLink(link string, rule *digestRule) bool {
+	host, _ := siteHostPath(link)
+	for _, serviceHost := range rule.serviceHosts {
+		if host == serviceHost || strings.HasSuffix(host, "."+serviceHost) {
+			return true
+		}
+	}
+	return false
+}
+
+// Link, title, date and content of the digest item element; relative links are resolved against `baseUrl`.
+// Nil is returned when the element is not an item.
+func parseDigestItem(item *goquery.Selection, rule *digestRule, headings string, baseUrl string) *digestItem {
+	var result digestItem
+
+	var heading *goquery.Selection
+	if len(rule.title) > 0 {
+		heading = item.Find(rule.title).First()
+	} else {
+		heading = item.Find(headings).FilterFunction(func(_ int, heading *goquery.Selection) bool {
+			return isDigestHeading(heading, headings)
+		}).First()
+	}
+
+	var link *goquery.Selection
+	if heading.Length() > 0 {
+		link = heading.Find("a[href]").AddSelection(heading.Filter("a[href]")).First()
+		result.title = strings.TrimSpace(heading.Text())
+	} else {
+		link = item.Find("a[href]").First()
+		result.title = strings.TrimSpace(link.Text())
+	}
+
+	if href, ok := link.Attr("href"); ok {
+		result.url = strings.TrimSpace(href)
+		if !url.IsAbsoluteURL(result.url) {
+			// without base the link would be neither a valid URL nor a stable hash of the item
+			result.url, _ = url.AbsoluteURL(baseUrl, result.url)
+			if !url.IsAbsoluteURL(result.url) {
+				result.url = ""
+			}
+		}
+	}
+
+	if isDigestServiceLink(result.url, rule) {
+		return nil
+	}
+
+	result.date = digestItemDate(item, rule)
+
+	// The title is shown by Miniflux separately
+	heading.Remove()
+	content, err := item.Html()
+	if err != nil {
+		return nil
+	}
+	result.content = strings.TrimSpace(content)
+
+	if len(result.title) == 0 && len(result.url) == 0 {
+		return nil
+	}
+
+	return &result
+}
+
+// Split the content of the digest entry into items by the rule; relative links are resolved
+// against `<base>` of the content, otherwise against `baseUrl`
+func splitDigest(content string, rule *digestRule, baseUrl string) []*digestItem {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return nil
+	}
+
+	if base, ok := doc.Find("base[href]").First().Attr("href"); ok {
+		if absolute, err := url.AbsoluteURL(baseUrl, strings.TrimSpace(base)); err == nil {
+			baseUrl = absolute
+		}
+	}
+
+	var elements []*goquery.Selection
+	headings := rule.headings
+	if len(rule.items) > 0 {
+		doc.Find(rule.items).Each(func(_ int, item *goquery.Selection) {
+			elements = append(elements, item)
+		})
+		headings = digestHeadings
+	} else {
+		elements = digestItemsByHeadings(doc, rule.headings)
+	}
+
+	var items []*digestItem
+	for _, element := range elements {
+		if item := parseDigestItem(element, rule, headings, baseUrl); item != nil {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// Create entries for the items of the digest entry; the entry itself is returned when it has no items
+func createDigestEntries(entry *model.Entry, rule *digestRule) model.Entries {
+	items := splitDigest(entry.Content, rule, entry.URL)
+	if len(items) == 0 {
+		return model.Entries{entry}
+	}
+
+	entries := make(model.Entries, 0, len(items))
+	for i, item := range items {
+		itemEntry := *entry
+		itemEntry.URL = item.url
+		itemEntry.Title = item.title
+		itemEntry.Content = item.content
+		itemEntry.Tags = append([]string(nil), entry.Tags...)
+		itemEntry.Enclosures = make(model.EnclosureList, 0)
+
+		if len(itemEntry.Title) == 0 {
+			itemEntry.Title = itemEntry.URL
+		}
+
+		// Items are deduplicated by link, as the entries fetched by Miniflux
+		if len(item.url) > 0 {
+			itemEntry.Hash = crypto.Hash(item.url)
+		} else {
+			itemEntry.Hash = crypto.Hash(fmt.Sprintf("%s#%d", entry.Hash, i))
+		}
+
+		if !item.date.IsZero() && !item.date.After(entry.CreatedAt) {
+			itemEntry.Date = item.date
+		}
+
+		entries = append(entries, &itemEntry)
+	}
+
+	return entries
+}
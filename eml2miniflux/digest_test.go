@@ -0,0 +1,288 @@
+package eml2miniflux
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/sg3des/eml"
+	"miniflux.app/crypto"
+	"miniflux.app/model"
+)
+
+func TestDigestItemDate(t *testing.T) {
+	tests := []struct {
+		html string
+		want time.Time
+	}{
+		{`<time datetime="2023-01-02T03:04:05Z">yesterday</time>`, time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{`<time datetime="2023-01-02">yesterday</time>`, time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{`<time>Mon, 02 Jan 2023 03:04:05 GMT</time>`, time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{`<p>Posted on Jan 2, 2023 by Alice</p>`, time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{`<p>Posted on 2 January 2023</p>`, time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{`<p>Posted 2023-01-02 03:04</p>`, time.Date(2023, 1, 2, 3, 4, 0, 0, time.UTC)},
+		{`<p>No date here</p>`, time.Time{}},
+	}
+
+	for _, test := range tests {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(test.html))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := digestItemDate(doc.Find("body"), digestTemplates["headings"]); !got.Equal(test.want) {
+			t.Errorf("digestItemDate(%q) = %v, want %v", test.html, got, test.want)
+		}
+	}
+}
+
+func TestProcessDigestLine(t *testing.T) {
+	tests := []struct {
+		line    string
+		wantErr bool
+	}{
+		{"https://example.com/feed.xml => template:headings", false},
+		{"https://example.com/feed.xml => div.item", false},
+		{"https://example.com/feed.xml => template:feedburner", false},
+		{"https://example.com/feed.xml => template:unknown", true},
+		{"https://example.com/feed.xml => div[", true},
+		{"https://example.com/other.xml => div.item", true},
+		{"https://example.com/feed.xml div.item", true},
+	}
+
+	for _, test := range tests {
+		h := newTestFeedHelper(t, "", &model.Feed{FeedURL: "https://example.com/feed.xml"})
+		h.digestRules = make(map[int64]*digestRule)
+		if err := h.processDigestLine(test.line); (err != nil) != test.wantErr {
+			t.Errorf("processDigestLine(%q) error = %v, want error %v", test.line, err, test.wantErr)
+		}
+	}
+}
+
+func TestSplitDigestItemDates(t *testing.T) {
+	content := `<h2><a href="https://example.com/a">A</a></h2><p>Posted on Jan 2, 2023</p>` +
+		`<h2><a href="https://example.com/b">B</a></h2><p><time datetime="2023-01-03T00:00:00Z">Jan 3</time></p>` +
+		`<h2><a href="https://example.com/c">C</a></h2><p>Undated</p>`
+
+	items := splitDigest(content, digestTemplates["headings"], "")
+	if len(items) != 3 {
+		t.Fatalf("got %d items, want 3", len(items))
+	}
+
+	want := []time.Time{
+		time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+		time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC),
+		{},
+	}
+	for i, item := range items {
+		if !item.date.Equal(want[i]) {
+			t.Errorf("item %s: date %v, want %v", item.url, item.date, want[i])
+		}
+	}
+}
+
+func TestSplitDigestTemplates(t *testing.T) {
+	tests := []struct {
+		template string
+		content  string
+		baseUrl  string
+		want     []digestItem
+	}{
+		{
+			template: "blogtrottr",
+			content: `<table><tr><td><h2><a href="https://blog.example.com/first">First post</a></h2>` +
+				`<p>Published Jan 2, 2023</p><p>First text</p></td></tr>` +
+				`<tr><td><h2><a href="https://blog.example.com/second">Second post</a></h2>` +
+				`<p>Published Jan 3, 2023</p><p>Second text</p></td></tr>` +
+				`<tr><td><h3><a href="https://blogtrottr.com/unsubscribe/abc">Unsubscribe</a></h3>` +
+				`<p>Delivered by Blogtrottr</p></td></tr></table>`,
+			want: []digestItem{
+				{title: "First post", url: "https://blog.example.com/first", date: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)},
+				{title: "Second post", url: "https://blog.example.com/second", date: time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			template: "feedburner",
+			content: `<div class="regularitem"><h4 class="itemtitle"><a href="/2023/01/first.html">First post</a></h4>` +
+				`<h5 class="itemposttime"><span>Posted:</span> Mon, 02 Jan 2023 03:04:05 GMT</h5>` +
+				`<div class="itemcontent">First text, see also Jan 9, 2023</div></div>` +
+				`<div class="regularitem"><h4 class="itemtitle"><a href="https://blog.example.com/2023/01/second.html">Second post</a></h4>` +
+				`<h5 class="itemposttime"><span>Posted:</span> Tue, 03 Jan 2023 03:04:05 GMT</h5>` +
+				`<div class="itemcontent">Second text</div></div>` +
+				`<div class="regularitem"><a href="https://feedburner.google.com/fb/a/mailverify">Manage subscription</a></div>`,
+			baseUrl: "https://blog.example.com/",
+			want: []digestItem{
+				{title: "First post", url: "https://blog.example.com/2023/01/first.html", date: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)},
+				{title: "Second post", url: "https://blog.example.com/2023/01/second.html", date: time.Date(2023, 1, 3, 3, 4, 5, 0, time.UTC)},
+			},
+		},
+		{
+			template: "ifttt",
+			content: `<div><b><a href="https://news.example.com/a">Story A</a></b><br>Text A<br>January 2, 2023 at 03:04PM</div>` +
+				`<div><b><a href="https://news.example.com/b">Story B</a></b><br>Text B<br>January 3, 2023 at 03:04PM</div>` +
+				`<div><strong><a href="https://ifttt.com/myrecipes">Manage applets</a></strong></div>`,
+			want: []digestItem{
+				{title: "Story A", url: "https://news.example.com/a", date: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)},
+				{title: "Story B", url: "https://news.example.com/b", date: time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			template: "headings",
+			content:  `<base href="https://example.org/news/"><h2><a href="item-1">One</a></h2><p>1</p><h2><a href="/item-2">Two</a></h2><p>2</p>`,
+			baseUrl:  "https://example.com/digest",
+			want: []digestItem{
+				{title: "One", url: "https://example.org/news/item-1"},
+				{title: "Two", url: "https://example.org/item-2"},
+			},
+		},
+		{
+			template: "headings",
+			content:  `<h2><a href="item-1">One</a></h2><p>1</p>`,
+			want:     []digestItem{{title: "One"}},
+		},
+	}
+
+	for _, test := range tests {
+		items := splitDigest(test.content, digestTemplates[test.template], test.baseUrl)
+		if len(items) != len(test.want) {
+			t.Errorf("%s: got %d items, want %d", test.template, len(items), len(test.want))
+			continue
+		}
+		for i, item := range items {
+			want := test.want[i]
+			if item.title != want.title || item.url != want.url || !item.date.Equal(want.date) {
+				t.Errorf("%s: item #%d: got %q %q %v, want %q %q %v",
+					test.template, i+1, item.title, item.url, item.date, want.title, want.url, want.date)
+			}
+		}
+	}
+}
+
+func TestCreateDigestEntriesRelativeLinks(t *testing.T) {
+	entry := &model.Entry{
+		URL:       "https://example.com/digest/2023-01",
+		Hash:      "digest",
+		Content:   `<h2><a href="../posts/a">A</a></h2><p>A</p><h2><a href="https://example.com/posts/b">B</a></h2><p>B</p>`,
+		CreatedAt: time.Now(),
+	}
+
+	entries := createDigestEntries(entry, digestTemplates["headings"])
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if entries[0].URL != "https://example.com/posts/a" || entries[0].Hash != crypto.Hash("https://example.com/posts/a") {
+		t.Errorf("got entry %q with hash %q", entries[0].URL, entries[0].Hash)
+	}
+}
+
+// Message linked to the URL, holding the attached messages as parts of the specified type
+func testMessageWithAttached(url string, contentType string, attached ...[]byte) []byte {
+	lines := []string{
+		"From: Digest <digest@example.com>",
+		"Subject: Digest",
+		"Date: Wed, 11 May 2016 14:31:59 +0000",
+		"Content-Base: " + url,
+		"Content-Type: " + contentType + `; boundary="BOUNDARY"`,
+		"",
+		"--BOUNDARY",
+		"Content-Type: text/html; charset=UTF-8",
+		"",
+		"<html><body><p>Digest</p></body></html>",
+	}
+	for _, message := range attached {
+		lines = append(lines, "--BOUNDARY")
+		if contentType != "multipart/digest" {
+			lines = append(lines, "Content-Type: message/rfc822")
+		}
+		lines = append(lines, "", string(message))
+	}
+	lines = append(lines, "--BOUNDARY--", "")
+	return []byte(strings.Join(lines, "\r\n"))
+}
+
+func TestCreateMessageEntriesAttached(t *testing.T) {
+	blog := [][]byte{
+		testFeedMessage("First", "https://example.com/blog/first"),
+		testFeedMessage("Second", "https://other.org/second"),
+	}
+	unknown := [][]byte{
+		testFeedMessage("Third", "https://unknown.net/third"),
+	}
+
+	tests := []struct {
+		name        string
+		parentUrl   string
+		contentType string
+		attached    [][]byte
+		digestRule  string
+		want        []string
+		wantFeeds   []string
+		wantErr     bool
+	}{
+		{
+			name: "forwarded", parentUrl: "https://example.com/digest", contentType: "multipart/mixed", attached: blog,
+			want: []string{"https://example.com/digest"}, wantFeeds: []string{"https://example.com/feed.xml"},
+		},
+		{
+			name: "forwarded with digest rule", parentUrl: "https://example.com/digest", contentType: "multipart/mixed", attached: blog,
+			digestRule: "https://example.com/feed.xml => template:headings",
+			want:       []string{"https://example.com/blog/first", "https://other.org/second"},
+			wantFeeds:  []string{"https://example.com/feed.xml", "https://example.com/feed.xml"},
+		},
+		{
+			name: "multipart/digest of the feed", parentUrl: "https://example.com/digest", contentType: "multipart/digest", attached: blog,
+			want:      []string{"https://example.com/blog/first", "https://other.org/second"},
+			wantFeeds: []string{"https://example.com/feed.xml", "https://example.com/feed.xml"},
+		},
+		{
+			name: "multipart/digest of unknown feed", parentUrl: "https://lists.unknown.net/digest", contentType: "multipart/digest", attached: blog,
+			want:      []string{"https://example.com/blog/first", "https://other.org/second"},
+			wantFeeds: []string{"https://example.com/feed.xml", "https://other.org/feed.xml"},
+		},
+		{
+			name: "multipart/digest without matching items", parentUrl: "https://lists.unknown.net/digest", contentType: "multipart/digest", attached: unknown,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		feedHelper := newTestFeedHelper(t, "example.com => https://example.com/feed.xml\nother.org => https://other.org/feed.xml",
+			&model.Feed{FeedURL: "https://example.com/feed.xml"},
+			&model.Feed{FeedURL: "https://other.org/feed.xml"})
+		rulesFile := filepath.Join(t.TempDir(), "digest.txt")
+		if err := os.WriteFile(rulesFile, []byte(test.digestRule), 0666); err != nil {
+			t.Fatal(err)
+		}
+		if err := feedHelper.LoadDigestRules(rulesFile); err != nil {
+			t.Fatal(err)
+		}
+
+		message, err := eml.Parse(testMessageWithAttached(test.parentUrl, test.contentType, test.attached...))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c := newEntryCollector(&EntryConfig{FeedHelper: feedHelper, User: &model.User{ID: 1}, Quiet: true})
+		entries, err := c.createMessageEntries("test", &message, &MessageInfo{}, nil)
+		if test.wantErr {
+			if _, ok := err.(*FeedNoMatchError); !ok {
+				t.Errorf("%s: got error %v, want no match", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		var urls, feeds []string
+		for _, entry := range entries {
+			urls = append(urls, entry.URL)
+			feeds = append(feeds, feedHelper.FeedByID(entry.FeedID).FeedURL)
+		}
+		if strings.Join(urls, " ") != strings.Join(test.want, " ") || strings.Join(feeds, " ") != strings.Join(test.wantFeeds, " ") {
+			t.Errorf("%s: got entries %v of feeds %v, want %v of %v", test.name, urls, feeds, test.want, test.wantFeeds)
+		}
+	}
+}
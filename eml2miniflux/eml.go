@@ -2,6 +2,7 @@ package eml2miniflux
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -9,9 +10,28 @@ import (
 	"github.com/a-ilin/eml2miniflux/util"
 	"github.com/sg3des/eml"
 	"miniflux.app/model"
-	"miniflux.app/storage"
 )
 
+// Get the value of the first message header with the specified name
+func messageHeader(message *eml.Message, key string) string {
+	for _, header := range message.FullHeaders {
+		if strings.EqualFold(header.Key, key) {
+			return strings.TrimSpace(header.Value)
+		}
+	}
+	return ""
+}
+
+func reportEntryError(source string, err error, quiet bool) {
+	if _, ok := err.(*FeedIgnoreError); ok {
+		// entry is ignored, be silent
+	} else if _, ok := err.(*FeedNoMatchError); ok && quiet {
+		// entry is not ignored, but quiet flag set, be silent
+	} else {
+		fmt.Fprintf(os.Stderr, "Error on processing file: %s: %s\n", source, err)
+	}
+}
+
 func loadEML(filePath string) (*eml.Message, error) {
 	raw, err := os.ReadFile(filePath)
 	if err != nil {
@@ -26,43 +46,175 @@ func loadEML(filePath string) (*eml.Message, error) {
 	return &message, nil
 }
 
-func emlToEntry(store *storage.Storage, feedHelper *FeedHelper, messageFile string, user *model.User, defaultFeed *model.Feed) (*model.Entry, error) {
-	// Load EML
-	message, err := loadEML(messageFile)
+// entryCollector creates entries from messages of various sources
+type entryCollector struct {
+	config *EntryConfig
+
+	entries      model.Entries
+	entryCounter int
+
+	// Input path; folders of the messages are matched by feed map relative to it
+	root string
+
+	// Thunderbird summaries of message folders
+	summaries map[string]msfSummary
+}
+
+func newEntryCollector(config *EntryConfig) *entryCollector {
+	return &entryCollector{
+		config:  config,
+		entries: model.Entries{},
+	}
+}
+
+func (c *entryCollector) countMessage() {
+	c.entryCounter++
+	if c.entryCounter%1000 == 0 {
+		fmt.Fprintf(os.Stdout, "Reading EML: %d\n", c.entryCounter)
+	}
+}
+
+// Path of the folder relative to the input, with '/' separators and without Thunderbird '.sbd' suffixes;
+// the folder name when the folder is the input itself
+func (c *entryCollector) folderPath(folder string) string {
+	if len(c.root) == 0 {
+		return filepath.ToSlash(folder)
+	}
+
+	rel := ThunderbirdFolderName(c.root, folder)
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, "../") {
+		return ThunderbirdFolderName(filepath.Dir(folder), folder)
+	}
+	return rel
+}
+
+// Create entries for the parsed message
+func (c *entryCollector) createEntries(source string, message *eml.Message, info *MessageInfo) (model.Entries, error) {
+	c.applyFolderSummary(message, info)
+	if info != nil && len(info.FolderPath) == 0 && len(info.Folder) > 0 {
+		info.FolderPath = c.folderPath(info.Folder)
+	}
+	return c.createMessageEntries(source, message, info, nil)
+}
+
+// Create entries for the message, or for the messages attached to it: the parts of `multipart/digest`,
+// or `message/rfc822` parts of a message whose feed has a digest rule. Otherwise the attached messages
+// are a part of the message, ex. a forwarded letter. The attached messages go to the feed of the message;
+// the parts of a digest matching no feed are matched each on its own. `parentFeed` is the feed
+// of the enclosing message, nil for a top-level message. The message itself is imported when
+// none of its attached messages gives an entry.
+func (c *entryCollector) createMessageEntries(source string, message *eml.Message, info *MessageInfo, parentFeed *model.Feed) (model.Entries, error) {
+	config := c.config
+	if parentFeed != nil {
+		parentConfig := *c.config
+		parentConfig.DefaultFeed = parentFeed
+		config = &parentConfig
+	}
+
+	isDigest := isDigestMessage(message)
+
+	entry, feed, err := createEntryForEML(message, info, config)
+	if _, ok := err.(*FeedNoMatchError); err != nil && !(ok && isDigest) {
+		return nil, err
+	}
+
+	if isDigest || config.FeedHelper.digestRuleForFeed(feed) != nil {
+		if entries := c.createAttachedEntries(source, message, info, feed); len(entries) > 0 {
+			return entries, nil
+		}
+	}
+
 	if err != nil {
-		return nil, fmt.Errorf("cannot parse EML: %s", err)
+		return nil, err
 	}
 
-	return CreateEntryForEML(message, store, feedHelper, user, defaultFeed)
+	return createFeedEntries(message, entry, feed, config), nil
 }
 
-// Recursively traverse directories and load *.eml files
-func emlWalkFunc(entries *model.Entries, entryCounter *int, store *storage.Storage, feedHelper *FeedHelper, user *model.User, defaultFeed *model.Feed, quiet bool) filepath.WalkFunc {
+// Create entries for the messages attached to the message, in the feed when it is known;
+// errors of the attached messages are reported rather than returned
+func (c *entryCollector) createAttachedEntries(source string, message *eml.Message, info *MessageInfo, feed *model.Feed) model.Entries {
+	attached, err := attachedMessages(message)
+	if err != nil {
+		reportEntryError(source, err, c.config.Quiet)
+	}
+
+	entries := model.Entries{}
+	for i, attachedMessage := range attached {
+		attachedSource := fmt.Sprintf("%s: attached message #%d", source, i+1)
+		attachedEntries, err := c.createMessageEntries(attachedSource, attachedMessage, info, feed)
+		if err != nil {
+			reportEntryError(attachedSource, err, c.config.Quiet)
+			continue
+		}
+		entries = append(entries, attachedEntries...)
+	}
+
+	return entries
+}
+
+// Create entries for the parsed message; the errors are reported rather than returned
+func (c *entryCollector) addMessage(source string, message *eml.Message, info *MessageInfo) {
+	entries, err := c.createEntries(source, message, info)
+	if err != nil {
+		reportEntryError(source, err, c.config.Quiet)
+	} else {
+		c.entries = append(c.entries, entries...)
+	}
+}
+
+// Create entries for the EML file. Only the failure to read the file is returned;
+// errors of the entries, ex. unmatched feed, are reported, as for the messages of a directory.
+func (c *entryCollector) addFile(path string, info *MessageInfo) error {
+	c.countMessage()
+
+	message, err := loadEML(path)
+	if err != nil {
+		reportEntryError(path, err, c.config.Quiet)
+		return err
+	}
+
+	c.addMessage(path, message, info)
+	return nil
+}
+
+// Recursively traverse directories and load *.eml, *.msg and mbox files, and messages of MH folders
+// and Maildirs; each directory is checked to be MH folder or Maildir on its own
+func (c *entryCollector) emlWalkFunc() filepath.WalkFunc {
+	folders := make(mhFolders)
+
 	return func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "FS Error: %s: %s\n", path, err)
 			return nil
 		}
 
-		if !info.IsDir() {
-			if strings.HasSuffix(strings.ToLower(path), ".eml") {
-				*entryCounter++
-				if *entryCounter%1000 == 0 {
-					fmt.Fprintf(os.Stdout, "Reading EML: %d\n", *entryCounter)
+		if info.IsDir() {
+			if isDir, err := util.IsDirectory(filepath.Join(path, "cur")); err == nil && isDir {
+				// Maildir keeps its flags in the file names, so it is read with its subfolders as Maildir
+				if err := filepath.Walk(path, c.maildirWalkFunc()); err != nil {
+					fmt.Fprintf(os.Stderr, "FS Error: %s: %s\n", path, err)
 				}
-
-				var entry *model.Entry
-				entry, err = emlToEntry(store, feedHelper, path, user, defaultFeed)
+				return filepath.SkipDir
+			}
+			folders.addDirectory(path)
+		} else {
+			if messageInfo := folders.messageInfo(path); messageInfo != nil {
+				c.addFile(path, messageInfo)
+			} else if strings.HasSuffix(strings.ToLower(path), ".eml") {
+				c.addFile(path, &MessageInfo{Folder: filepath.Dir(path)})
+			} else if IsMsgFile(path) {
+				c.addMsgFile(path)
+			} else {
+				var isMbox bool
+				isMbox, err = IsMboxFile(path)
 				if err != nil {
-					if _, ok := err.(*FeedIgnoreError); ok {
-						// entry is ignored, be silent
-					} else if _, ok := err.(*FeedNoMatchError); ok && quiet {
-						// entry is not ignored, but quiet flag set, be silent
-					} else {
-						fmt.Fprintf(os.Stderr, "Error on processing file: %s: %s\n", path, err)
+					fmt.Fprintf(os.Stderr, "FS Error: %s: %s\n", path, err)
+				} else if isMbox {
+					err = c.addMbox(path)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Error on processing file: %s\n", err)
 					}
-				} else {
-					*entries = append(*entries, entry)
 				}
 			}
 		}
@@ -72,37 +224,56 @@ func emlWalkFunc(entries *model.Entries, entryCounter *int, store *storage.Stora
 }
 
 // Load EML from the specified messagesPath and create model Entry
-// - if messagesPath is a directory: traverse recursively and load all *.eml files
+// - if messagesPath is a directory: traverse recursively and load all *.eml, *.msg and mbox files, MH folders and Maildirs
+// - if messagesPath is a mbox file: load all messages from it
 // - otherwise load a single file
-func GetEntriesForEML(store *storage.Storage, feedHelper *FeedHelper, messagesPath string, user *model.User, defaultFeed *model.Feed, quiet bool) (model.Entries, error) {
+func GetEntriesForEML(config *EntryConfig, messagesPath string) (model.Entries, error) {
 	var err error
-	entries := model.Entries{}
+	c := newEntryCollector(config)
+	c.root = messagesPath
 
 	isDir, err := util.IsDirectory(messagesPath)
 	if err != nil {
-		return entries, fmt.Errorf("cannot read path: %s %v", messagesPath, err)
+		return c.entries, fmt.Errorf("cannot read path: %s %v", messagesPath, err)
 	}
 
-	entryCounter := 0
-
 	if isDir {
-		err = filepath.Walk(messagesPath, emlWalkFunc(&entries, &entryCounter, store, feedHelper, user, defaultFeed, quiet))
-		fmt.Fprintf(os.Stdout, "Reading EML completed. Processed files: %d\n", entryCounter)
-	} else {
-		var entry *model.Entry
-		entry, err = emlToEntry(store, feedHelper, messagesPath, user, defaultFeed)
-		if err != nil {
-			if _, ok := err.(*FeedIgnoreError); ok {
-				// entry is ignored, be silent
-			} else if _, ok := err.(*FeedNoMatchError); ok && quiet {
-				// entry is not ignored, but quiet flag set, be silent
-			} else {
-				fmt.Fprintf(os.Stderr, "Error on processing file: %s: %s\n", messagesPath, err)
-			}
-		} else {
-			entries = append(entries, entry)
-		}
+		err = filepath.Walk(messagesPath, c.emlWalkFunc())
+		fmt.Fprintf(os.Stdout, "Reading EML completed. Processed files: %d\n", c.entryCounter)
+		return c.entries, err
+	}
+
+	isMbox, err := IsMboxFile(messagesPath)
+	if err != nil {
+		return c.entries, fmt.Errorf("cannot read path: %s %v", messagesPath, err)
+	}
+
+	if isMbox {
+		return GetEntriesForMbox(config, messagesPath)
+	}
+
+	err = c.addFile(messagesPath, &MessageInfo{Folder: filepath.Dir(messagesPath)})
+	return c.entries, err
+}
+
+// Load a single EML message from the reader `r` and create model Entry;
+// `source` names the reader in error messages
+func GetEntriesForEMLReader(config *EntryConfig, source string, r io.Reader) (model.Entries, error) {
+	c := newEntryCollector(config)
+	c.countMessage()
+
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return c.entries, fmt.Errorf("cannot read %s: %s", source, err)
+	}
+
+	message, err := eml.Parse(raw)
+	if err != nil {
+		err = fmt.Errorf("cannot parse EML: %s", err)
+		reportEntryError(source, err, config.Quiet)
+		return c.entries, err
 	}
 
-	return entries, err
+	c.addMessage(source, &message, &MessageInfo{})
+	return c.entries, nil
 }
@@ -1,6 +1,7 @@
 package eml2miniflux
 
 import (
+	"html"
 	"math"
 	"regexp"
 	"strings"
@@ -20,9 +21,89 @@ var (
 	bodyRx                    = regexp.MustCompile(`(?s)<body(?:[^>]*)?>(.*)<\/body>`)
 	feedEntryContentRx        = regexp.MustCompile(`(?s)div\s+class="feedEntryContent">\s*(.*)<\/div>\s*<div\s+class="feedEntryLinks">`)
 	feedEntryAlternateLinksRx = regexp.MustCompile(`(?s)<ul\s+class="feedEntryAlternateLinks">\s*<li>\s*<a\s+href="([^"]+)"`)
+	// Windows Live Mail puts the item title linked to the article into a heading
+	headingLinkRx = regexp.MustCompile(`(?is)<h[1-3][^>]*>\s*<a\s[^>]*?href="([^"]+)"`)
 )
 
-func CreateEntryForEML(message *eml.Message, store *storage.Storage, feedHelper *FeedHelper, user *model.User, defaultFeed *model.Feed) (*model.Entry, error) {
+// EntryConfig holds parameters of entry creation common for all messages
+type EntryConfig struct {
+	Store       *storage.Storage
+	FeedHelper  *FeedHelper
+	User        *model.User
+	DefaultFeed *model.Feed
+	// Suppress output about unmatched messages
+	Quiet bool
+	// Status of entries whose state is not known from the message or its storage;
+	// unread when empty
+	DefaultStatus string
+	// Messages are email newsletters: matched by list headers and sender, cleaned of footers
+	Newsletter bool
+	// Template of the URL of Usenet articles, with {messageid} and {group} placeholders;
+	// `news:` URL when empty
+	NewsUrlTemplate string
+	// Log in to IMAP server without TLS, when it does not support STARTTLS
+	ImapInsecure bool
+}
+
+// MessageInfo holds message properties which are defined by the message storage
+// rather than by the message content
+type MessageInfo struct {
+	// Path of the folder (directory, mbox file) containing the message
+	Folder string
+	// Path of the folder relative to the input, with '/' separators
+	FolderPath string
+
+	// Flags below are known from the storage
+	HasFlags bool
+	Seen     bool
+	Flagged  bool
+
+	// Feed known from the storage, ex. properties of Outlook RSS item
+	FeedUrl   string
+	FeedTitle string
+}
+
+func CreateEntryForEML(message *eml.Message, info *MessageInfo, config *EntryConfig) (*model.Entry, error) {
+	entry, feed, err := createEntryForEML(message, info, config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rewrite and sanitize content
+	rewriteEntry(entry, config.User, feed)
+
+	return entry, nil
+}
+
+// CreateEntriesForEML creates entries for the message: an entry per item when the feed
+// of the message has a digest rule, otherwise a single entry
+func CreateEntriesForEML(message *eml.Message, info *MessageInfo, config *EntryConfig) (model.Entries, error) {
+	entry, feed, err := createEntryForEML(message, info, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return createFeedEntries(message, entry, feed, config), nil
+}
+
+// Split the entry of the message by the digest rule of the feed, and rewrite the content of the entries
+func createFeedEntries(message *eml.Message, entry *model.Entry, feed *model.Feed, config *EntryConfig) model.Entries {
+	entries := model.Entries{entry}
+	if rule := config.FeedHelper.digestRuleForFeed(feed); rule != nil && len(message.Html) > 0 {
+		entries = createDigestEntries(entry, rule)
+	}
+
+	for _, entry := range entries {
+		rewriteEntry(entry, config.User, feed)
+	}
+
+	return entries
+}
+
+// Create entry for the message, and find its feed; the content is neither rewritten nor sanitized yet
+func createEntryForEML(message *eml.Message, info *MessageInfo, config *EntryConfig) (*model.Entry, *model.Feed, error) {
+	mozState := mozillaMessageState(message)
+
 	entry := model.Entry{
 		Status:     model.EntryStatusUnread,
 		Title:      message.Subject,
@@ -31,11 +112,45 @@ func CreateEntryForEML(message *eml.Message, store *storage.Storage, feedHelper
 		CreatedAt:  message.ReceivedDate,
 		ChangedAt:  message.ReceivedDate,
 		Enclosures: make(model.EnclosureList, 0),
-		Tags:       message.Keywords,
+		Tags:       mergeTags(message.Keywords, mozState.Tags),
+	}
+
+	var feedKeys []string
+	if config.Newsletter {
+		if len(entry.URL) == 0 {
+			entry.URL = newsletterUrl(message)
+		}
+		feedKeys = newsletterKeys(message)
+	}
+
+	// Usenet articles are matched by their groups, and linked to the thread by the first reference
+	if newsgroups := usenetNewsgroups(message); len(newsgroups) > 0 {
+		if len(entry.URL) == 0 {
+			entry.URL = usenetArticleUrl(message.MessageId, newsgroups[0], config.NewsUrlTemplate)
+		}
+		if len(message.References) > 0 {
+			entry.CommentsURL = usenetArticleUrl(message.References[0], newsgroups[0], config.NewsUrlTemplate)
+		}
+		feedKeys = append(feedKeys, newsgroups...)
 	}
 
 	entry.Hash = entryHash(message, entry.URL)
 
+	// State known from the storage takes precedence over the headers
+	if info != nil && info.HasFlags {
+		if info.Seen {
+			entry.Status = model.EntryStatusRead
+		}
+		entry.Starred = info.Flagged
+	} else if mozState.HasStatus {
+		if mozState.Read {
+			entry.Status = model.EntryStatusRead
+		}
+		entry.Starred = mozState.Marked
+	} else if len(config.DefaultStatus) > 0 {
+		entry.Status = config.DefaultStatus
+	}
+
 	if !message.ReceivedDate.IsZero() {
 		entry.CreatedAt = message.ReceivedDate
 		entry.ChangedAt = message.ReceivedDate
@@ -63,28 +178,97 @@ func CreateEntryForEML(message *eml.Message, store *storage.Storage, feedHelper
 
 	if len(message.Html) > 0 {
 		entry.Content = extractBody(message.Html)
+		if config.Newsletter {
+			entry.Content = cleanNewsletter(entry.Content)
+		}
 	} else {
 		entry.Content = message.Text
 	}
 
 	// Assign User & Feed
-	feed, err := assignUserFeed(&entry, store, user, feedHelper, defaultFeed)
+	feed, err := assignUserFeed(&entry, info, newMessageFields(message, info), feedKeys, config.User, config.FeedHelper, config.DefaultFeed)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	// Rewrite and sanitize content
-	rewriteEntry(&entry, user, feed)
-
-	return &entry, nil
+	return &entry, feed, nil
 }
 
-func assignUserFeed(entry *model.Entry, store *storage.Storage, user *model.User, feedHelper *FeedHelper, defaultFeed *model.Feed) (*model.Feed, error) {
+// Assign user and feed to the entry. Feed map rules targeting the fields of the message are applied first;
+// besides the entry URL, the rules of URL are applied to `feedKeys` which identify the source of the message,
+// ex. List-Id of a newsletter.
+func assignUserFeed(entry *model.Entry, info *MessageInfo, fields *messageFields, feedKeys []string, user *model.User, feedHelper *FeedHelper, defaultFeed *model.Feed) (*model.Feed, error) {
 	var err error
 
 	feed := defaultFeed
-	if defaultFeed == nil {
+	if feed == nil && info != nil && len(info.FeedUrl) > 0 {
+		feed = feedHelper.FeedByURL(info.FeedUrl)
+	}
+	if feed == nil && info != nil && len(info.FeedTitle) > 0 {
+		feed = feedHelper.FeedByTitle(info.FeedTitle)
+	}
+	if feed == nil && info != nil && len(info.Folder) > 0 {
+		// Subscription of the folder is more precise than matching by entry URL
+		feed = feedHelper.FeedForFolder(info.Folder, entry.URL)
+	}
+	if feed == nil && fields != nil {
+		feed, err = feedHelper.feedForMessageFields(fields)
+		if _, ok := err.(*FeedNoMatchError); ok {
+			feed = nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, key := range feedKeys {
+		if feed != nil {
+			break
+		}
+		feed, err = feedHelper.FeedForEntryUrl(key)
+		if _, ok := err.(*FeedNoMatchError); ok {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	if feed == nil {
 		feed, err = feedHelper.FeedForEntryUrl(entry.URL)
+		if _, ok := err.(*FeedNoMatchError); ok {
+			// The site of the feed is the last resort, if enabled
+			if siteFeed := feedHelper.autoMatchFeed(entry.URL); siteFeed != nil {
+				feed, err = siteFeed, nil
+			} else {
+				feedHelper.recordUnmatched(entry.URL)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	entry.UserID = user.ID
+	entry.FeedID = feed.ID
+
+	return feed, nil
+}
+
+// Assign user and feed to the entry of a feed reader database, where the feed of the entry is known.
+// The feed with the same URL is used, otherwise the feed map is applied to the feed URL.
+func assignUserFeedByFeedUrl(entry *model.Entry, feedUrl string, user *model.User, feedHelper *FeedHelper, defaultFeed *model.Feed) (*model.Feed, error) {
+	var err error
+
+	feed := defaultFeed
+	if feed == nil {
+		feed = feedHelper.FeedByURL(feedUrl)
+	}
+
+	if feed == nil {
+		feed, err = feedHelper.FeedForEntryUrl(feedUrl)
+		if _, ok := err.(*FeedNoMatchError); ok {
+			// The feed map is applied to the feed URL, so the scan proposes rules for it
+			feedHelper.recordUnmatched(feedUrl)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -125,9 +309,27 @@ func entryUrl(message *eml.Message) string {
 		return strings.TrimSpace(match[1])
 	}
 
+	if isWindowsLiveMail(message) {
+		// Windows Live Mail sets Content-Location to the article, or links it from the title
+		if location := messageHeader(message, "Content-Location"); len(location) > 0 {
+			return location
+		}
+
+		match = headingLinkRx.FindStringSubmatch(message.Html)
+		if len(match) >= 2 {
+			return html.UnescapeString(strings.TrimSpace(match[1]))
+		}
+	}
+
 	return ""
 }
 
+// Determine if the message is stored by Windows Live Mail (or Windows Mail)
+func isWindowsLiveMail(message *eml.Message) bool {
+	return len(messageHeader(message, "X-MimeOLE")) > 0 ||
+		strings.Contains(messageHeader(message, "X-Mailer"), "Windows Live Mail")
+}
+
 func entryHash(message *eml.Message, entryUrl string) string {
 	// remove suffix added by Thunderbird
 	msgId := strings.TrimSuffix(message.MessageId, "@localhost.localdomain")
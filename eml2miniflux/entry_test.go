@@ -0,0 +1,57 @@
+package eml2miniflux
+
+import "testing"
+
+func TestEntryUrlWindowsLiveMail(t *testing.T) {
+	heading := `<html><body><h2 class="title"><a target="_blank" href=" https://example.com/post?a=1&amp;b=2 ">Post</a></h2><p>Text</p></body></html>`
+
+	tests := []struct {
+		name    string
+		headers []string
+		body    string
+		want    string
+	}{
+		{
+			name:    "Content-Location",
+			headers: []string{"X-MimeOLE: Produced By Microsoft MimeOLE V6.00.2900.5931", "Content-Location: https://example.com/location"},
+			body:    heading,
+			want:    "https://example.com/location",
+		},
+		{
+			name:    "heading link",
+			headers: []string{"X-MimeOLE: Produced By Microsoft MimeOLE V6.00.2900.5931"},
+			body:    heading,
+			want:    "https://example.com/post?a=1&b=2",
+		},
+		{
+			name:    "heading link, Windows Live Mail by X-Mailer",
+			headers: []string{"X-Mailer: Microsoft Windows Live Mail 16.4.3528.331"},
+			body:    heading,
+			want:    "https://example.com/post?a=1&b=2",
+		},
+		{
+			name:    "Content-Base comes first",
+			headers: []string{"X-MimeOLE: Produced By Microsoft MimeOLE V6.00.2900.5931", "Content-Base: https://example.com/base", "Content-Location: https://example.com/location"},
+			body:    heading,
+			want:    "https://example.com/base",
+		},
+		{
+			name:    "link of a heading below the title",
+			headers: []string{"X-MimeOLE: Produced By Microsoft MimeOLE V6.00.2900.5931"},
+			body:    `<html><body><h4><a href="https://example.com/post">Post</a></h4></body></html>`,
+			want:    "",
+		},
+		{
+			name: "not Windows Live Mail",
+			body: heading,
+			want: "",
+		},
+	}
+
+	for _, test := range tests {
+		message := testHtmlMessage(t, test.headers, test.body)
+		if got := entryUrl(message); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
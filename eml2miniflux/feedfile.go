@@ -0,0 +1,188 @@
+package eml2miniflux
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"miniflux.app/model"
+	"miniflux.app/reader/parser"
+	"miniflux.app/url"
+)
+
+// Saved feed documents by file name suffix
+var feedFileSuffixes = []string{".xml", ".rss", ".atom"}
+
+// IsFeedFile determines if a file represented by `filePath`
+// is a saved RSS or Atom document by its name
+func IsFeedFile(filePath string) bool {
+	name := strings.ToLower(filePath)
+	for _, suffix := range feedFileSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Create entry for the item parsed by Miniflux, which keeps the hash Miniflux computes for the live feed
+func createEntryForFeedFileItem(entry *model.Entry, feedUrl string, config *EntryConfig) (*model.Entry, error) {
+	entry.Status = model.EntryStatusUnread
+	if len(config.DefaultStatus) > 0 {
+		entry.Status = config.DefaultStatus
+	}
+
+	if entry.Date.IsZero() {
+		entry.Date = time.Now()
+	}
+	entry.CreatedAt = entry.Date
+	entry.ChangedAt = entry.Date
+
+	if entry.Enclosures == nil {
+		entry.Enclosures = make(model.EnclosureList, 0)
+	}
+	entry.Tags = mergeTags(entry.Tags)
+
+	feed, err := assignUserFeedByFeedUrl(entry, feedUrl, config.User, config.FeedHelper, config.DefaultFeed)
+	if err != nil {
+		return nil, err
+	}
+
+	rewriteEntry(entry, config.User, feed)
+
+	return entry, nil
+}
+
+// Base URL of the saved document: its self or site URL, otherwise URL of the feed from command line
+func feedFileBaseUrl(parsedFeed *model.Feed, defaultFeed *model.Feed) string {
+	for _, u := range []string{parsedFeed.FeedURL, parsedFeed.SiteURL} {
+		if url.IsAbsoluteURL(u) {
+			return u
+		}
+	}
+
+	if defaultFeed != nil {
+		return defaultFeed.FeedURL
+	}
+
+	return ""
+}
+
+// Feed of the saved document: the feed from command line, otherwise the feed of its self link, by URL
+// or by the feed map. The self link is missing in some RSS feeds, and the site link does not name
+// a feed, so it is matched by the feed map and, if enabled, by site URLs of the feeds.
+// The URL the feed is looked for by is returned to report the unmatched document.
+func feedFileFeed(parsedFeed *model.Feed, config *EntryConfig) (*model.Feed, string, error) {
+	if config.DefaultFeed != nil {
+		return config.DefaultFeed, config.DefaultFeed.FeedURL, nil
+	}
+
+	feedHelper := config.FeedHelper
+	if len(parsedFeed.FeedURL) > 0 {
+		if feed := feedHelper.FeedByURL(parsedFeed.FeedURL); feed != nil {
+			return feed, parsedFeed.FeedURL, nil
+		}
+		feed, err := feedHelper.FeedForEntryUrl(parsedFeed.FeedURL)
+		if _, ok := err.(*FeedNoMatchError); !ok {
+			return feed, parsedFeed.FeedURL, err
+		}
+	}
+
+	if len(parsedFeed.SiteURL) == 0 {
+		return nil, parsedFeed.FeedURL, &FeedNoMatchError{entryUrl: parsedFeed.FeedURL}
+	}
+
+	feed, err := feedHelper.FeedForEntryUrl(parsedFeed.SiteURL)
+	if _, ok := err.(*FeedNoMatchError); ok {
+		if siteFeed := feedHelper.autoMatchFeed(parsedFeed.SiteURL); siteFeed != nil {
+			return siteFeed, parsedFeed.SiteURL, nil
+		}
+	}
+	return feed, parsedFeed.SiteURL, err
+}
+
+// Error of the document matching no feed, telling how to specify the feed
+func feedFileNoMatchError(matchUrl string) error {
+	if len(matchUrl) == 0 {
+		return fmt.Errorf("document has neither feed nor site link; specify its feed with -feed option")
+	}
+	return fmt.Errorf("feed of the document is not found: %q; specify it with -feed option or a feed map rule", matchUrl)
+}
+
+// Load items from RSS or Atom document `r` and create model Entry for each of them;
+// `source` names the document in error messages
+func GetEntriesForFeedFileReader(config *EntryConfig, source string, r io.Reader) (model.Entries, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return model.Entries{}, fmt.Errorf("cannot read feed: %s", err)
+	}
+
+	parsedFeed, localizedErr := parser.ParseFeed("", string(data))
+	if localizedErr != nil {
+		return model.Entries{}, fmt.Errorf("cannot parse feed: %s", localizedErr)
+	}
+
+	// Relative links of the items are resolved against the base URL, so the document is parsed again with it
+	if baseUrl := feedFileBaseUrl(parsedFeed, config.DefaultFeed); len(baseUrl) > 0 {
+		parsedFeed, localizedErr = parser.ParseFeed(baseUrl, string(data))
+		if localizedErr != nil {
+			return model.Entries{}, fmt.Errorf("cannot parse feed: %s", localizedErr)
+		}
+	}
+
+	c := newEntryCollector(config)
+
+	// The items share the feed, so it is looked for once; the items of an unmatched document
+	// are counted as unmatched, and the document is reported rather than returned as an error
+	feed, matchUrl, err := feedFileFeed(parsedFeed, config)
+	if err != nil {
+		for range parsedFeed.Entries {
+			c.countMessage()
+		}
+
+		unmatched := 0
+		if _, ok := err.(*FeedNoMatchError); ok {
+			unmatched = c.entryCounter
+			for i := 0; i < unmatched; i++ {
+				config.FeedHelper.recordUnmatched(matchUrl)
+			}
+			if !config.Quiet {
+				err = feedFileNoMatchError(matchUrl)
+			}
+		}
+		reportEntryError(source, err, config.Quiet)
+
+		fmt.Fprintf(os.Stdout, "Reading feed completed. Processed items: %d, not matching any feed: %d\n", c.entryCounter, unmatched)
+		return c.entries, nil
+	}
+
+	itemConfig := *config
+	itemConfig.DefaultFeed = feed
+
+	for _, item := range parsedFeed.Entries {
+		c.countMessage()
+
+		entry, err := createEntryForFeedFileItem(item, feed.FeedURL, &itemConfig)
+		if err != nil {
+			reportEntryError(fmt.Sprintf("%s: %s", source, item.URL), err, config.Quiet)
+		} else {
+			c.entries = append(c.entries, entry)
+		}
+	}
+	fmt.Fprintf(os.Stdout, "Reading feed completed. Processed items: %d\n", c.entryCounter)
+
+	return c.entries, nil
+}
+
+// Load items from RSS or Atom file specified by filePath and create model Entry for each of them
+func GetEntriesForFeedFile(config *EntryConfig, filePath string) (model.Entries, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return model.Entries{}, fmt.Errorf("cannot open file: %s", err)
+	}
+	defer file.Close()
+
+	return GetEntriesForFeedFileReader(config, filePath, file)
+}
@@ -0,0 +1,131 @@
+package eml2miniflux
+
+import (
+	"strings"
+	"testing"
+
+	"miniflux.app/model"
+)
+
+func TestGetEntriesForFeedFileRelativeLinks(t *testing.T) {
+	goFeed := &model.Feed{FeedURL: "https://go.dev/blog/feed.atom", Title: "Go Blog"}
+
+	tests := []struct {
+		name     string
+		document string
+		feed     *model.Feed
+		want     string
+	}{
+		{
+			name: "atom with self link",
+			document: `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Go Blog</title>
+  <link rel="self" href="https://go.dev/blog/feed.atom"/>
+  <link rel="alternate" href="/blog/"/>
+  <entry><id>tag:go.dev,2024:1</id><title>Post</title><link rel="alternate" href="post"/><updated>2024-01-01T00:00:00Z</updated></entry>
+</feed>`,
+			want: "https://go.dev/blog/post",
+		},
+		{
+			name: "rss with site link",
+			document: `<?xml version="1.0"?>
+<rss version="2.0"><channel>
+  <title>Go Blog</title><link>https://go.dev/blog/</link>
+  <item><title>Post</title><link>/blog/post</link><guid>1</guid></item>
+</channel></rss>`,
+			want: "https://go.dev/blog/post",
+		},
+		{
+			name: "rss without links, feed from command line",
+			document: `<?xml version="1.0"?>
+<rss version="2.0"><channel>
+  <title>Go Blog</title>
+  <item><title>Post</title><link>post</link><guid>1</guid></item>
+</channel></rss>`,
+			feed: goFeed,
+			want: "https://go.dev/blog/post",
+		},
+	}
+
+	feedHelper := newTestFeedHelper(t, "go.dev => https://go.dev/blog/feed.atom\n", goFeed)
+
+	for _, test := range tests {
+		config := &EntryConfig{FeedHelper: feedHelper, User: &model.User{ID: 1}, DefaultFeed: test.feed}
+		entries, err := GetEntriesForFeedFileReader(config, test.name, strings.NewReader(test.document))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		if len(entries) != 1 {
+			t.Errorf("%s: got %d entries", test.name, len(entries))
+			continue
+		}
+		if entries[0].URL != test.want || entries[0].FeedID != goFeed.ID {
+			t.Errorf("%s: got URL %s, feed %d", test.name, entries[0].URL, entries[0].FeedID)
+		}
+	}
+}
+
+func TestGetEntriesForFeedFileUnknownFeed(t *testing.T) {
+	document := `<?xml version="1.0"?>
+<rss version="2.0"><channel>
+  <title>Unknown</title><link>https://unknown.example.org/</link>
+  <item><title>First</title><link>/first</link><guid>1</guid></item>
+  <item><title>Second</title><link>/second</link><guid>2</guid></item>
+</channel></rss>`
+
+	feedHelper := newTestFeedHelper(t, "go.dev => https://go.dev/blog/feed.atom\n", &model.Feed{FeedURL: "https://go.dev/blog/feed.atom"})
+	config := &EntryConfig{FeedHelper: feedHelper, User: &model.User{ID: 1}, Quiet: true}
+
+	entries, err := GetEntriesForFeedFileReader(config, "unknown", strings.NewReader(document))
+	if err != nil || len(entries) != 0 {
+		t.Errorf("got %d entries, error %v; want the document reported and no entries", len(entries), err)
+	}
+
+	feedHelper.EnableScan()
+	entries, err = GetEntriesForFeedFileReader(config, "unknown", strings.NewReader(document))
+	if err != nil || len(entries) != 0 {
+		t.Errorf("scan: got %d entries, error %v", len(entries), err)
+	}
+	if !strings.Contains(feedHelper.ScanMap(), "unknown.example.org") {
+		t.Errorf("scan: unmatched feed is not listed:\n%s", feedHelper.ScanMap())
+	}
+}
+
+func TestGetEntriesForFeedFileSiteLink(t *testing.T) {
+	document := `<?xml version="1.0"?>
+<rss version="2.0"><channel>
+  <title>Blog</title><link>https://www.example.org/blog/</link>
+  <item><title>Post</title><link>/blog/post</link><guid>1</guid></item>
+</channel></rss>`
+
+	tests := []struct {
+		name      string
+		feedMap   string
+		autoMatch bool
+		want      bool
+	}{
+		{name: "no rule", want: false},
+		{name: "host rule", feedMap: "host:example.org => https://example.org/feed.xml\n", want: true},
+		{name: "site URL of the feed", autoMatch: true, want: true},
+	}
+
+	for _, test := range tests {
+		feed := &model.Feed{FeedURL: "https://example.org/feed.xml", SiteURL: "https://example.org/blog"}
+		feedHelper := newTestFeedHelper(t, test.feedMap, feed)
+		if test.autoMatch {
+			feedHelper.EnableAutoMatch()
+		}
+		config := &EntryConfig{FeedHelper: feedHelper, User: &model.User{ID: 1}, Quiet: true}
+
+		entries, err := GetEntriesForFeedFileReader(config, test.name, strings.NewReader(document))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		if got := len(entries) == 1 && entries[0].FeedID == feed.ID; got != test.want {
+			t.Errorf("%s: got %d entries, matched %v, want %v", test.name, len(entries), got, test.want)
+		}
+	}
+}
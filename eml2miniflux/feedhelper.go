@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	"miniflux.app/model"
@@ -11,9 +12,31 @@ import (
 )
 
 type FeedHelper struct {
-	feedsLookup map[string]*model.Feed
+	feedRules   []*feedRule
 	feedsUrl    map[string]*model.Feed
 	feedsId     map[int64]*model.Feed
+	feedsTitle  map[string]*model.Feed
+	feedsFolder map[string][]string
+
+	// Rules splitting digests, by feed ID
+	digestRules map[int64]*digestRule
+
+	// Entry URLs matched by rules of different feeds, in order of matching
+	ambiguousMatches []AmbiguousMatch
+	ambiguousUrls    map[string]bool
+
+	// Matching by site URLs of the feeds, when enabled
+	autoMatcher *autoMatcher
+
+	// Entries matching no feed, collected by scan
+	unmatched *unmatchedScan
+}
+
+// AmbiguousMatch holds the entry URL (or the message, for the rules targeting its fields)
+// matched by several feed map rules of different feeds; the first rule is the applied one
+type AmbiguousMatch struct {
+	EntryUrl string
+	Rules    []string
 }
 
 type FeedIgnoreError struct{}
@@ -50,6 +73,7 @@ func (h *FeedHelper) loadAllFeeds(store *storage.Storage, user *model.User) erro
 
 	h.feedsUrl = make(map[string]*model.Feed)
 	h.feedsId = make(map[int64]*model.Feed)
+	h.feedsTitle = make(map[string]*model.Feed)
 
 	// special case to allow entry ignoring
 	h.feedsUrl["none"] = nil
@@ -57,13 +81,24 @@ func (h *FeedHelper) loadAllFeeds(store *storage.Storage, user *model.User) erro
 	for _, feed := range allFeeds {
 		h.feedsUrl[feed.FeedURL] = feed
 		h.feedsId[feed.ID] = feed
+
+		if _, ok := h.feedsTitle[feed.Title]; ok {
+			// ambiguous title does not identify the feed
+			h.feedsTitle[feed.Title] = nil
+		} else {
+			h.feedsTitle[feed.Title] = feed
+		}
 	}
 
 	return err
 }
 
+// LoadMap loads the feed map. The rule with the longest pattern is applied when several rules match
+// the entry; rules of the same pattern length are tried in the order of the file.
 func (h *FeedHelper) LoadMap(fileName string) error {
-	h.feedsLookup = make(map[string]*model.Feed)
+	h.feedRules = nil
+	h.ambiguousMatches = nil
+	h.ambiguousUrls = make(map[string]bool)
 
 	file, err := os.Open(fileName)
 	if err != nil {
@@ -75,7 +110,7 @@ func (h *FeedHelper) LoadMap(fileName string) error {
 	lineNum := 1
 	for scanner.Scan() {
 		line := scanner.Text()
-		err = h.processConfigLine(line)
+		err = h.processConfigLine(line, lineNum)
 		if err != nil {
 			return fmt.Errorf(`wrong feed helper line #%d: %s: %s`, lineNum, err, line)
 		}
@@ -86,10 +121,15 @@ func (h *FeedHelper) LoadMap(fileName string) error {
 		return fmt.Errorf("cannot read feed helper file: %s", err)
 	}
 
+	// The longest pattern is the most specific one
+	sort.SliceStable(h.feedRules, func(i, j int) bool {
+		return h.feedRules[i].specificity() > h.feedRules[j].specificity()
+	})
+
 	return nil
 }
 
-func (h *FeedHelper) processConfigLine(line string) error {
+func (h *FeedHelper) processConfigLine(line string, lineNum int) error {
 	line = strings.TrimSpace(line)
 
 	if len(line) == 0 {
@@ -116,27 +156,117 @@ func (h *FeedHelper) processConfigLine(line string) error {
 		return fmt.Errorf(`feed URL is missing`)
 	}
 
-	if feed, ok := h.feedsUrl[feedUrl]; ok {
-		h.feedsLookup[entryUrl] = feed
-	} else {
-		return fmt.Errorf(`cannot find feed with URL: %s`, feedUrl)
+	rule, err := parseFeedRule(entryUrl, feedUrl, lineNum)
+	if err != nil {
+		return err
+	}
+
+	// Feed URL referring to captures is known only on matching
+	if !rule.hasCaptureReferences() {
+		feed, ok := h.feedsUrl[feedUrl]
+		if !ok {
+			return fmt.Errorf(`cannot find feed with URL: %s`, feedUrl)
+		}
+		rule.feed = feed
 	}
 
+	h.feedRules = append(h.feedRules, rule)
 	return nil
 }
 
 func (h *FeedHelper) FeedForEntryUrl(entryUrl string) (*model.Feed, error) {
-	for e, f := range h.feedsLookup {
-		if strings.Contains(entryUrl, e) {
-			if f == nil {
-				return nil, &FeedIgnoreError{}
-			}
+	feed, err := h.feedForRules(entryUrl, func(rule *feedRule) (string, bool) {
+		if len(rule.target) > 0 {
+			return "", false
+		}
+		return rule.match(entryUrl)
+	})
+	if _, ok := err.(*FeedNoMatchError); ok {
+		return nil, &FeedNoMatchError{entryUrl: entryUrl}
+	}
+	return feed, err
+}
+
+// Feed of the message by the rules targeting its fields
+func (h *FeedHelper) feedForMessageFields(fields *messageFields) (*model.Feed, error) {
+	return h.feedForRules(fields.String(), func(rule *feedRule) (string, bool) {
+		if len(rule.target) == 0 {
+			return "", false
+		}
+		value := fields.value(rule.target)
+		if len(value) == 0 {
+			return "", false
+		}
+		return rule.match(value)
+	})
+}
+
+// Feed by the most specific of the rules matched by `match`; `subject` names the matched entry in reports
+func (h *FeedHelper) feedForRules(subject string, match func(rule *feedRule) (string, bool)) (*model.Feed, error) {
+	var matched []*feedRule
+	var feedUrls []string
+	for _, rule := range h.feedRules {
+		if feedUrl, ok := match(rule); ok {
+			matched = append(matched, rule)
+			feedUrls = append(feedUrls, feedUrl)
+		}
+	}
+
+	if len(matched) == 0 {
+		return nil, &FeedNoMatchError{}
+	}
+
+	h.checkAmbiguousMatch(subject, matched, feedUrls)
+
+	feed := matched[0].feed
+	if matched[0].hasCaptureReferences() {
+		var ok bool
+		feed, ok = h.feedsUrl[feedUrls[0]]
+		if !ok {
+			return nil, fmt.Errorf("cannot find feed with URL: %s (feed map %s)", feedUrls[0], matched[0])
+		}
+	}
+
+	if feed == nil {
+		return nil, &FeedIgnoreError{}
+	}
+
+	return feed, nil
+}
 
-			return f, nil
+// Remember the entry URL when the matched rules lead to different feeds
+func (h *FeedHelper) checkAmbiguousMatch(entryUrl string, matched []*feedRule, feedUrls []string) {
+	if h.ambiguousUrls[entryUrl] {
+		return
+	}
+
+	ambiguous := false
+	for _, feedUrl := range feedUrls[1:] {
+		if feedUrl != feedUrls[0] {
+			ambiguous = true
+			break
+		}
+	}
+	if !ambiguous {
+		return
+	}
+
+	match := AmbiguousMatch{EntryUrl: entryUrl}
+	for i, rule := range matched {
+		if feedUrls[i] != rule.feedUrl {
+			match.Rules = append(match.Rules, fmt.Sprintf("%s (%s)", rule, feedUrls[i]))
+		} else {
+			match.Rules = append(match.Rules, rule.String())
 		}
 	}
 
-	return nil, &FeedNoMatchError{entryUrl: entryUrl}
+	h.ambiguousUrls[entryUrl] = true
+	h.ambiguousMatches = append(h.ambiguousMatches, match)
+}
+
+// AmbiguousMatches returns the entry URLs matched by feed map rules of different feeds
+func (h *FeedHelper) AmbiguousMatches() []AmbiguousMatch {
+	return h.ambiguousMatches
 }
 
 func (h *FeedHelper) FeedByID(feedId int64) *model.Feed {
@@ -148,3 +278,9 @@ func (h *FeedHelper) FeedByURL(feedUrl string) *model.Feed {
 	feed := h.feedsUrl[feedUrl]
 	return feed
 }
+
+// Feed with the title, nil when several feeds have it
+func (h *FeedHelper) FeedByTitle(title string) *model.Feed {
+	feed := h.feedsTitle[title]
+	return feed
+}
@@ -0,0 +1,206 @@
+package eml2miniflux
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sg3des/eml"
+	"miniflux.app/model"
+)
+
+func TestParseFeedRule(t *testing.T) {
+	tests := []struct {
+		pattern string
+		feedUrl string
+		target  string
+		kind    int
+		value   string
+		wantErr bool
+	}{
+		{pattern: "example.com/blog", target: "", kind: feedRuleSubstring, value: "example.com/blog"},
+		{pattern: "host:.Example.COM", target: "", kind: feedRuleHost, value: "example.com"},
+		{pattern: "prefix:https://example.com/", target: "", kind: feedRulePrefix, value: "https://example.com/"},
+		{pattern: `re:^https://example\.com/(\w+)/`, feedUrl: "https://example.com/$1.xml", kind: feedRuleRegexp, value: `^https://example\.com/(\w+)/`},
+		{pattern: "glob:https://*.example.com/?", target: "", kind: feedRuleGlob, value: "https://*.example.com/?"},
+		{pattern: "from:news@example.com", target: "from", kind: feedRuleSubstring, value: "news@example.com"},
+		{pattern: "listid:host:example.com", target: "listid", kind: feedRuleHost, value: "example.com"},
+		{pattern: "folder:prefix:Feeds/Blog", target: "folder", kind: feedRulePrefix, value: "Feeds/Blog"},
+		{pattern: "header:x-mozilla-keys:re:^news$", target: "header:X-Mozilla-Keys", kind: feedRuleRegexp, value: "^news$"},
+		{pattern: "header::news", wantErr: true},
+		{pattern: "header:X-Keys", wantErr: true},
+		{pattern: "subject:", wantErr: true},
+		{pattern: "host:", wantErr: true},
+		{pattern: "re:(", wantErr: true},
+		{pattern: "prefix:https://example.com/", feedUrl: "https://example.com/$1.xml", wantErr: true},
+		{pattern: "prefix:https://example.com/", feedUrl: "https://example.com/feed?a=$x", kind: feedRulePrefix, value: "https://example.com/"},
+	}
+
+	for _, test := range tests {
+		feedUrl := test.feedUrl
+		if len(feedUrl) == 0 {
+			feedUrl = "https://example.com/feed.xml"
+		}
+
+		rule, err := parseFeedRule(test.pattern, feedUrl, 1)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("parseFeedRule(%q): error expected", test.pattern)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseFeedRule(%q): %s", test.pattern, err)
+			continue
+		}
+		if rule.target != test.target || rule.kind != test.kind || rule.value != test.value {
+			t.Errorf("parseFeedRule(%q) = %q, %d, %q, want %q, %d, %q",
+				test.pattern, rule.target, rule.kind, rule.value, test.target, test.kind, test.value)
+		}
+	}
+}
+
+func TestFeedForEntryUrl(t *testing.T) {
+	feedMap := strings.Join([]string{
+		"# the shorter rules come first, the order of the file does not matter",
+		"example.com => https://example.com/feed.xml",
+		"example.com/blog/ => https://example.com/blog/feed.xml",
+		"example.com/blog/drafts/ => none",
+		"",
+		"# rules of the same length are tried in the order of the file",
+		"host:first.org => https://first.org/feed.xml",
+		"first.org => https://other.org/feed.xml",
+		"glob:https://*.other.org/* => https://other.org/feed.xml",
+		"",
+		`re:^https?://(www\.)?medium\.com/@(\w+)/ => https://medium.com/feed/@$2`,
+		`re:^https://example\.net/(\w+)/ => https://example.net/$1.xml`,
+		"",
+		"# `$` not referring to a capture is a literal character",
+		`re:^https://example\.io/ => https://example.io/feed?a=$x`,
+		`re:^https://(?P<name>\w+)\.example\.io/ => https://example.io/feed?a=$x&b=${name}`,
+	}, "\n")
+
+	feedHelper := newTestFeedHelper(t, feedMap,
+		&model.Feed{FeedURL: "https://example.com/feed.xml"},
+		&model.Feed{FeedURL: "https://example.com/blog/feed.xml"},
+		&model.Feed{FeedURL: "https://first.org/feed.xml"},
+		&model.Feed{FeedURL: "https://other.org/feed.xml"},
+		&model.Feed{FeedURL: "https://medium.com/feed/@alice"},
+		&model.Feed{FeedURL: "https://example.io/feed?a=$x"},
+		&model.Feed{FeedURL: "https://example.io/feed?a=$x&b=news"},
+	)
+
+	tests := []struct {
+		entryUrl string
+		feedUrl  string
+		err      string
+	}{
+		{"https://example.com/about", "https://example.com/feed.xml", ""},
+		{"https://example.com/blog/post", "https://example.com/blog/feed.xml", ""},
+		{"https://example.com/blog/drafts/post", "", "ignore"},
+		{"https://www.first.org/post", "https://first.org/feed.xml", ""},
+		{"https://first.org/post", "https://first.org/feed.xml", ""},
+		{"https://www.other.org/post", "https://other.org/feed.xml", ""},
+		{"https://other.org/post", "", "nomatch"},
+		{"https://medium.com/@alice/post", "https://medium.com/feed/@alice", ""},
+		{"https://www.medium.com/@alice/post", "https://medium.com/feed/@alice", ""},
+		{"https://medium.com/@bob/post", "", "error"},
+		{"https://example.io/post", "https://example.io/feed?a=$x", ""},
+		{"https://news.example.io/post", "https://example.io/feed?a=$x&b=news", ""},
+		{"https://unknown.org/post", "", "nomatch"},
+		{"", "", "nomatch"},
+	}
+
+	for _, test := range tests {
+		feed, err := feedHelper.FeedForEntryUrl(test.entryUrl)
+
+		errKind := ""
+		switch err.(type) {
+		case nil:
+		case *FeedIgnoreError:
+			errKind = "ignore"
+		case *FeedNoMatchError:
+			errKind = "nomatch"
+		default:
+			errKind = "error"
+		}
+
+		if errKind != test.err {
+			t.Errorf("FeedForEntryUrl(%q): error %v, want %s", test.entryUrl, err, test.err)
+		} else if err == nil && feed.FeedURL != test.feedUrl {
+			t.Errorf("FeedForEntryUrl(%q) = %s, want %s", test.entryUrl, feed.FeedURL, test.feedUrl)
+		}
+	}
+
+	// Rules of different feeds matching the same URL are reported, the applied one first
+	var rules []string
+	for _, match := range feedHelper.AmbiguousMatches() {
+		if match.EntryUrl == "https://www.first.org/post" {
+			rules = match.Rules
+		}
+	}
+	if len(rules) != 2 || !strings.HasPrefix(rules[0], "line #7: host:first.org") {
+		t.Errorf("got ambiguous match rules %v", rules)
+	}
+}
+
+func TestFeedForMessageFields(t *testing.T) {
+	feedMap := strings.Join([]string{
+		"from:news@example.com => https://example.com/feed.xml",
+		"listid:host:lists.example.org => https://example.org/feed.xml",
+		"folder:prefix:Feeds/Go Blog => https://go.dev/blog/feed.atom",
+		"header:X-Mozilla-Keys:re:(^| )golang( |$) => https://go.dev/blog/feed.atom",
+		"subject:[Spam] => none",
+	}, "\n")
+
+	feedHelper := newTestFeedHelper(t, feedMap,
+		&model.Feed{FeedURL: "https://example.com/feed.xml"},
+		&model.Feed{FeedURL: "https://example.org/feed.xml"},
+		&model.Feed{FeedURL: "https://go.dev/blog/feed.atom"},
+	)
+
+	tests := []struct {
+		headers map[string][]string
+		folder  string
+		feedUrl string
+		err     string
+	}{
+		{map[string][]string{"From": {"News <news@example.com>"}}, "", "https://example.com/feed.xml", ""},
+		{map[string][]string{"List-Id": {"Weekly <weekly.lists.example.org>"}}, "", "", "nomatch"},
+		{map[string][]string{}, "Feeds/Go Blog/2023", "https://go.dev/blog/feed.atom", ""},
+		{map[string][]string{"X-Mozilla-Keys": {"$label1 golang"}}, "", "https://go.dev/blog/feed.atom", ""},
+		{map[string][]string{"X-Mozilla-Keys": {"golangish"}}, "", "", "nomatch"},
+		{map[string][]string{"Subject": {"[Spam] Offer"}}, "", "", "ignore"},
+		{map[string][]string{"From": {"Other <other@example.com>"}}, "Feeds/Other", "", "nomatch"},
+	}
+
+	for i, test := range tests {
+		var message eml.Message
+		for name, values := range test.headers {
+			for _, value := range values {
+				message.FullHeaders = append(message.FullHeaders, eml.Header{Key: name, Value: value})
+			}
+		}
+		if subject, ok := test.headers["Subject"]; ok {
+			message.Subject = subject[0]
+		}
+
+		feed, err := feedHelper.feedForMessageFields(newMessageFields(&message, &MessageInfo{FolderPath: test.folder}))
+
+		errKind := ""
+		switch err.(type) {
+		case nil:
+		case *FeedIgnoreError:
+			errKind = "ignore"
+		case *FeedNoMatchError:
+			errKind = "nomatch"
+		default:
+			errKind = "error"
+		}
+
+		if errKind != test.err {
+			t.Errorf("test #%d: error %v, want %s", i+1, err, test.err)
+		} else if err == nil && feed.FeedURL != test.feedUrl {
+			t.Errorf("test #%d: got feed %s, want %s", i+1, feed.FeedURL, test.feedUrl)
+		}
+	}
+}
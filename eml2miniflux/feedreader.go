@@ -0,0 +1,165 @@
+package eml2miniflux
+
+import (
+	"database/sql"
+	"fmt"
+	"html"
+	"os"
+	"strings"
+	"time"
+
+	"miniflux.app/crypto"
+	"miniflux.app/model"
+	"miniflux.app/reader/sanitizer"
+)
+
+// Item of a feed reader database or export (Newsboat, Liferea, QuiteRSS, Google Reader, TT-RSS)
+type feedReaderItem struct {
+	guid          string
+	title         string
+	author        string
+	url           string
+	feedUrl       string
+	published     time.Time
+	content       string
+	plainText     bool
+	read          bool
+	starred       bool
+	enclosureUrl  string
+	enclosureType string
+	tags          []string
+	// Read state is not kept by the feed reader, so the default status applies
+	unknownState bool
+}
+
+// Function reading items of the feed reader database and calling `fn` for each of them
+type feedReaderDbLoader func(db *sql.DB, fn func(item *feedReaderItem) error) error
+
+// Function reading items of the feed reader export and calling `fn` for each of them
+type feedReaderLoader func(fn func(item *feedReaderItem) error) error
+
+// Parse time with the first matching layout; zero time is returned when none matches
+func parseTimeLayouts(value string, layouts []string) time.Time {
+	for _, layout := range layouts {
+		if date, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			return date
+		}
+	}
+	return time.Time{}
+}
+
+// Convert plain text content to HTML
+func textToHtml(text string) string {
+	return strings.ReplaceAll(html.EscapeString(text), "\n", "<br>\n")
+}
+
+// Hash of the item, calculated the same way as Miniflux does for RSS items: by GUID, otherwise by link.
+// Items having neither are hashed by title and content, or by title and publication date
+// when the content is empty, so they do not collide as duplicates.
+func feedReaderItemHash(item *feedReaderItem) string {
+	switch {
+	case len(item.guid) > 0:
+		return crypto.Hash(item.guid)
+	case len(item.url) > 0:
+		return crypto.Hash(item.url)
+	case len(item.content) > 0:
+		return crypto.Hash(item.title + item.content)
+	}
+	return crypto.Hash(item.title + item.published.UTC().Format(time.RFC3339))
+}
+
+// Create entry for the item of a feed reader database. The feed is found by URL of the item feed,
+// so the feed map, if any, is applied to the feed URL.
+func createEntryForFeedReaderItem(item *feedReaderItem, config *EntryConfig) (*model.Entry, error) {
+	entry := model.Entry{
+		Status:     model.EntryStatusUnread,
+		Title:      item.title,
+		URL:        item.url,
+		Author:     item.author,
+		Content:    item.content,
+		Starred:    item.starred,
+		Enclosures: make(model.EnclosureList, 0),
+		Tags:       mergeTags(item.tags),
+	}
+
+	if item.unknownState {
+		if len(config.DefaultStatus) > 0 {
+			entry.Status = config.DefaultStatus
+		}
+	} else if item.read {
+		entry.Status = model.EntryStatusRead
+	}
+
+	entry.Hash = feedReaderItemHash(item)
+
+	if !item.published.IsZero() {
+		entry.Date = item.published
+	} else {
+		entry.Date = time.Now()
+	}
+	entry.CreatedAt = entry.Date
+	entry.ChangedAt = entry.Date
+
+	if item.plainText {
+		entry.Content = textToHtml(entry.Content)
+	}
+
+	if len(entry.Title) == 0 {
+		entry.Title = sanitizer.TruncateHTML(entry.Content, 100)
+	}
+
+	if len(entry.Title) == 0 {
+		entry.Title = entry.URL
+	}
+
+	if len(item.enclosureUrl) > 0 {
+		entry.Enclosures = append(entry.Enclosures, &model.Enclosure{
+			URL:      item.enclosureUrl,
+			MimeType: item.enclosureType,
+		})
+	}
+
+	feed, err := assignUserFeedByFeedUrl(&entry, item.feedUrl, config.User, config.FeedHelper, config.DefaultFeed)
+	if err != nil {
+		return nil, err
+	}
+
+	rewriteEntry(&entry, config.User, feed)
+
+	return &entry, nil
+}
+
+// Load items of the feed reader with the loader and create model Entry for each of them;
+// `source` names the database or export in error messages
+func getEntriesForFeedReader(config *EntryConfig, readerName string, source string, loader feedReaderLoader) (model.Entries, error) {
+	c := newEntryCollector(config)
+
+	err := loader(func(item *feedReaderItem) error {
+		c.countMessage()
+
+		entry, err := createEntryForFeedReaderItem(item, config)
+		if err != nil {
+			reportEntryError(fmt.Sprintf("%s: %s", source, item.url), err, config.Quiet)
+		} else {
+			c.entries = append(c.entries, entry)
+		}
+		return nil
+	})
+	fmt.Fprintf(os.Stdout, "Reading %s completed. Processed items: %d\n", readerName, c.entryCounter)
+
+	return c.entries, err
+}
+
+// Load items of the feed reader database specified by dbPath with the loader,
+// and create model Entry for each of them
+func getEntriesForFeedReaderDb(config *EntryConfig, readerName string, dbPath string, loader feedReaderDbLoader) (model.Entries, error) {
+	db, err := openSqlite(dbPath)
+	if err != nil {
+		return model.Entries{}, err
+	}
+	defer db.Close()
+
+	return getEntriesForFeedReader(config, readerName, dbPath, func(fn func(item *feedReaderItem) error) error {
+		return loader(db, fn)
+	})
+}
@@ -0,0 +1,42 @@
+package eml2miniflux
+
+import (
+	"testing"
+	"time"
+
+	"miniflux.app/crypto"
+)
+
+func TestFeedReaderItemHash(t *testing.T) {
+	published := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		item feedReaderItem
+		want string
+	}{
+		{"guid", feedReaderItem{guid: "tag:example.com,2023:1", url: "https://example.com/1"}, crypto.Hash("tag:example.com,2023:1")},
+		{"url", feedReaderItem{url: "https://example.com/1", title: "One"}, crypto.Hash("https://example.com/1")},
+		{"title and content", feedReaderItem{title: "One", content: "<p>Text</p>"}, crypto.Hash("One<p>Text</p>")},
+		{"title and date", feedReaderItem{title: "One", published: published}, crypto.Hash("One2023-01-02T03:04:05Z")},
+	}
+
+	hashes := make(map[string]string)
+	for _, test := range tests {
+		got := feedReaderItemHash(&test.item)
+		if got != test.want {
+			t.Errorf("%s: got hash %s, want %s", test.name, got, test.want)
+		}
+		if other, ok := hashes[got]; ok {
+			t.Errorf("%s: hash collides with %s", test.name, other)
+		}
+		hashes[got] = test.name
+	}
+
+	// Items without GUID and link differ by title or content
+	first := feedReaderItemHash(&feedReaderItem{title: "One", content: "A"})
+	second := feedReaderItemHash(&feedReaderItem{title: "Two", content: "A"})
+	if first == second || first == crypto.Hash("") {
+		t.Errorf("items without GUID and link collide: %s, %s", first, second)
+	}
+}
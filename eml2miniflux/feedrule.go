@@ -0,0 +1,270 @@
+package eml2miniflux
+
+import (
+	"fmt"
+	"net/textproto"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sg3des/eml"
+	"miniflux.app/model"
+)
+
+// Kinds of feed map rules, by the prefix of the pattern; a pattern without prefix is a substring
+const (
+	feedRuleSubstring = iota
+	feedRuleHost
+	feedRulePrefix
+	feedRuleRegexp
+	feedRuleGlob
+)
+
+var feedRuleKinds = []struct {
+	prefix string
+	kind   int
+}{
+	{prefix: "host:", kind: feedRuleHost},
+	{prefix: "prefix:", kind: feedRulePrefix},
+	{prefix: "re:", kind: feedRuleRegexp},
+	{prefix: "glob:", kind: feedRuleGlob},
+}
+
+// Fields of the message targeted by feed map rules, by the prefix of the pattern;
+// `header:Name:` targets any header of the message
+var feedRuleTargets = []string{"from:", "sender:", "listid:", "subject:", "folder:"}
+
+const feedRuleHeaderTarget = "header:"
+
+// Reference to a capture of regular expression in the feed URL, ex.: `$1` or `${name}`;
+// `$` which does not refer to a capture of the rule is a literal character of the URL
+var (
+	captureReferenceRx         = regexp.MustCompile(`\$(\w+|\{\w+\})`)
+	numberedCaptureReferenceRx = regexp.MustCompile(`\$(\d+\b|\{\d+\})`)
+)
+
+// Rule of the feed map: entries matching the pattern go to the feed, or are ignored when the feed is nil.
+// The pattern is matched against the entry URL, or against the field of the message named by `target`.
+// Feed URL of a regular expression rule may refer to its captures, then the feed is found on matching.
+type feedRule struct {
+	target  string
+	kind    int
+	pattern string
+	value   string
+	rx      *regexp.Regexp
+	feedUrl string
+	feed    *model.Feed
+	lineNum int
+
+	// Template of the feed URL expanding the captures, empty when the feed URL does not refer to them
+	template string
+}
+
+// Parse the pattern of the feed map rule, ex.: `host:example.com` or `re:^https://example\.com/(\w+)/`
+func parseFeedRule(pattern string, feedUrl string, lineNum int) (*feedRule, error) {
+	rule := feedRule{kind: feedRuleSubstring, pattern: pattern, value: pattern, feedUrl: feedUrl, lineNum: lineNum}
+
+	for _, target := range feedRuleTargets {
+		if strings.HasPrefix(pattern, target) {
+			rule.target = strings.TrimSuffix(target, ":")
+			rule.value = strings.TrimSpace(strings.TrimPrefix(pattern, target))
+			break
+		}
+	}
+
+	if strings.HasPrefix(pattern, feedRuleHeaderTarget) {
+		name, value, found := strings.Cut(strings.TrimPrefix(pattern, feedRuleHeaderTarget), ":")
+		name = strings.TrimSpace(name)
+		if !found || len(name) == 0 {
+			return nil, fmt.Errorf(`header name is missing`)
+		}
+		rule.target = feedRuleHeaderTarget + textproto.CanonicalMIMEHeaderKey(name)
+		rule.value = strings.TrimSpace(value)
+	}
+
+	for _, kind := range feedRuleKinds {
+		if strings.HasPrefix(rule.value, kind.prefix) {
+			rule.kind = kind.kind
+			rule.value = strings.TrimSpace(strings.TrimPrefix(rule.value, kind.prefix))
+			break
+		}
+	}
+
+	if len(rule.value) == 0 && len(rule.target) > 0 {
+		return nil, fmt.Errorf(`pattern is missing`)
+	} else if len(rule.value) == 0 {
+		return nil, fmt.Errorf(`entry URL is missing`)
+	}
+
+	var err error
+	switch rule.kind {
+	case feedRuleHost:
+		rule.value = strings.ToLower(strings.TrimPrefix(rule.value, "."))
+	case feedRuleRegexp:
+		rule.rx, err = regexp.Compile(rule.value)
+		if err != nil {
+			return nil, fmt.Errorf(`wrong regular expression: %s`, err)
+		}
+	case feedRuleGlob:
+		rule.rx, err = regexp.Compile(globToRegexp(rule.value))
+		if err != nil {
+			return nil, fmt.Errorf(`wrong glob: %s`, err)
+		}
+	}
+
+	if rule.kind == feedRuleRegexp {
+		rule.template = captureTemplate(rule.rx, feedUrl)
+	} else if numberedCaptureReferenceRx.MatchString(feedUrl) {
+		return nil, fmt.Errorf(`feed URL refers to captures of non-regexp rule`)
+	}
+
+	return &rule, nil
+}
+
+// Regular expression matching the whole URL by the glob: `*` matches any sequence of characters, `?` a single one
+func globToRegexp(glob string) string {
+	var rx strings.Builder
+	rx.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			rx.WriteString(".*")
+		case '?':
+			rx.WriteString(".")
+		default:
+			rx.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	rx.WriteString("$")
+	return rx.String()
+}
+
+// Template of the feed URL for `Regexp.ExpandString`: `$` is escaped unless it refers to a capture
+// of the regular expression, ex.: `?a=$x` stays as is when there is no capture named `x`.
+// The template is empty when the feed URL has no references to the captures.
+func captureTemplate(rx *regexp.Regexp, feedUrl string) string {
+	var template strings.Builder
+	hasReferences := false
+
+	last := 0
+	for _, loc := range captureReferenceRx.FindAllStringSubmatchIndex(feedUrl, -1) {
+		template.WriteString(strings.ReplaceAll(feedUrl[last:loc[0]], "$", "$$"))
+		last = loc[1]
+
+		name := strings.Trim(feedUrl[loc[2]:loc[3]], "{}")
+		if n, err := strconv.Atoi(name); (err == nil && n <= rx.NumSubexp()) || (err != nil && rx.SubexpIndex(name) >= 0) {
+			template.WriteString(feedUrl[loc[0]:loc[1]])
+			hasReferences = true
+		} else {
+			template.WriteString("$" + feedUrl[loc[0]:loc[1]])
+		}
+	}
+	template.WriteString(strings.ReplaceAll(feedUrl[last:], "$", "$$"))
+
+	if !hasReferences {
+		return ""
+	}
+	return template.String()
+}
+
+// Determine if the feed URL of the rule is defined by captures of the pattern
+func (r *feedRule) hasCaptureReferences() bool {
+	return len(r.template) > 0
+}
+
+// Specificity of the rule: amount of the literal characters of the pattern; wildcards of glob are not counted
+func (r *feedRule) specificity() int {
+	if r.kind == feedRuleGlob {
+		return len(r.value) - strings.Count(r.value, "*") - strings.Count(r.value, "?")
+	}
+	return len(r.value)
+}
+
+// Match the entry URL or the targeted field, and return the feed URL for it
+func (r *feedRule) match(entryUrl string) (string, bool) {
+	switch r.kind {
+	case feedRuleHost:
+		u, err := url.Parse(entryUrl)
+		if err != nil {
+			return "", false
+		}
+		host := strings.ToLower(u.Hostname())
+		if host != r.value && !strings.HasSuffix(host, "."+r.value) {
+			return "", false
+		}
+	case feedRulePrefix:
+		if !strings.HasPrefix(entryUrl, r.value) {
+			return "", false
+		}
+	case feedRuleRegexp:
+		captures := r.rx.FindStringSubmatchIndex(entryUrl)
+		if captures == nil {
+			return "", false
+		}
+		if r.hasCaptureReferences() {
+			return string(r.rx.ExpandString(nil, r.template, entryUrl, captures)), true
+		}
+	case feedRuleGlob:
+		if !r.rx.MatchString(entryUrl) {
+			return "", false
+		}
+	default:
+		if !strings.Contains(entryUrl, r.value) {
+			return "", false
+		}
+	}
+
+	return r.feedUrl, true
+}
+
+func (r *feedRule) String() string {
+	return fmt.Sprintf("line #%d: %s => %s", r.lineNum, r.pattern, r.feedUrl)
+}
+
+// Fields of the message matched by feed map rules which target them
+type messageFields struct {
+	message *eml.Message
+	folder  string
+}
+
+// Fields of the message; the folder is known from the storage
+func newMessageFields(message *eml.Message, info *MessageInfo) *messageFields {
+	fields := messageFields{message: message}
+	if info != nil {
+		fields.folder = info.FolderPath
+	}
+	return &fields
+}
+
+// Value of the field targeted by the rule
+func (f *messageFields) value(target string) string {
+	switch target {
+	case "from":
+		if len(f.message.From) > 0 {
+			return f.message.From[0].String()
+		}
+		return messageHeader(f.message, "From")
+	case "sender":
+		if f.message.Sender != nil {
+			return f.message.Sender.String()
+		}
+		return ""
+	case "listid":
+		return messageHeader(f.message, "List-Id")
+	case "subject":
+		return f.message.Subject
+	case "folder":
+		return f.folder
+	}
+
+	return messageHeader(f.message, strings.TrimPrefix(target, feedRuleHeaderTarget))
+}
+
+// Name of the message in reports
+func (f *messageFields) String() string {
+	if len(f.message.MessageId) > 0 {
+		return fmt.Sprintf("message <%s>", strings.Trim(f.message.MessageId, "<>"))
+	}
+	return fmt.Sprintf("message '%s'", f.message.Subject)
+}
@@ -0,0 +1,220 @@
+package eml2miniflux
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"miniflux.app/model"
+)
+
+const (
+	// Prefix of the stream ID of a feed, ex.: `feed/https://example.com/rss.xml`
+	googleReaderFeedPrefix = "feed/"
+
+	// Suffixes of the category IDs, ex.: `user/-/state/com.google/read`, `user/123/label/News`
+	googleReaderReadState       = "/state/com.google/read"
+	googleReaderKeptUnreadState = "/state/com.google/kept-unread"
+	googleReaderStarredState    = "/state/com.google/starred"
+	googleReaderLabel           = "/label/"
+)
+
+// Integer which is written either as a number or as a string, ex.: `"crawlTimeMsec": "1400000000000"`
+type googleReaderInt int64
+
+func (i *googleReaderInt) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if len(value) == 0 || value == "null" {
+		*i = 0
+		return nil
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	*i = googleReaderInt(n)
+	return nil
+}
+
+type googleReaderLink struct {
+	Href string `json:"href"`
+	Type string `json:"type"`
+}
+
+type googleReaderContent struct {
+	Content string `json:"content"`
+}
+
+// Item of Google Reader API stream, as exported by Google Takeout, FreshRSS, Inoreader or The Old Reader
+type googleReaderItem struct {
+	ID            string              `json:"id"`
+	Title         string              `json:"title"`
+	Author        string              `json:"author"`
+	Published     googleReaderInt     `json:"published"`
+	CrawlTimeMsec googleReaderInt     `json:"crawlTimeMsec"`
+	Categories    []string            `json:"categories"`
+	Canonical     []googleReaderLink  `json:"canonical"`
+	Alternate     []googleReaderLink  `json:"alternate"`
+	Enclosure     []googleReaderLink  `json:"enclosure"`
+	Summary       googleReaderContent `json:"summary"`
+	Content       googleReaderContent `json:"content"`
+	Origin        struct {
+		StreamID string `json:"streamId"`
+		Title    string `json:"title"`
+		HtmlUrl  string `json:"htmlUrl"`
+	} `json:"origin"`
+}
+
+// IsGoogleReaderJson determines if a JSON file represented by `filePath`
+// is Google Reader API stream, i.e. JSON object rather than the entries dump (JSON array)
+func IsGoogleReaderJson(filePath string) bool {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	header, _ := bufio.NewReader(file).Peek(512)
+	header = bytes.TrimLeft(header, " \t\r\n")
+	return len(header) > 0 && header[0] == '{'
+}
+
+func (item *googleReaderItem) feedReaderItem() *feedReaderItem {
+	result := &feedReaderItem{
+		title:   item.Title,
+		author:  item.Author,
+		content: item.Content.Content,
+	}
+
+	for _, links := range [][]googleReaderLink{item.Canonical, item.Alternate} {
+		if len(links) > 0 && len(links[0].Href) > 0 {
+			result.url = links[0].Href
+			break
+		}
+	}
+
+	// Google Reader item ID is not the original GUID, so it is used only when the link is missing
+	if len(result.url) == 0 {
+		result.guid = item.ID
+	}
+
+	if len(result.content) == 0 {
+		result.content = item.Summary.Content
+	}
+
+	if strings.HasPrefix(item.Origin.StreamID, googleReaderFeedPrefix) {
+		result.feedUrl = strings.TrimPrefix(item.Origin.StreamID, googleReaderFeedPrefix)
+	}
+
+	if item.Published > 0 {
+		result.published = time.Unix(int64(item.Published), 0)
+	} else if item.CrawlTimeMsec > 0 {
+		result.published = time.UnixMilli(int64(item.CrawlTimeMsec))
+	}
+
+	if len(item.Enclosure) > 0 {
+		result.enclosureUrl = item.Enclosure[0].Href
+		result.enclosureType = item.Enclosure[0].Type
+	}
+
+	keptUnread := false
+	for _, category := range item.Categories {
+		switch {
+		case strings.HasSuffix(category, googleReaderReadState):
+			result.read = true
+		case strings.HasSuffix(category, googleReaderKeptUnreadState):
+			keptUnread = true
+		case strings.HasSuffix(category, googleReaderStarredState):
+			result.starred = true
+		case strings.Contains(category, googleReaderLabel):
+			label := category[strings.Index(category, googleReaderLabel)+len(googleReaderLabel):]
+			result.tags = append(result.tags, label)
+		}
+	}
+	if keptUnread {
+		result.read = false
+	}
+
+	return result
+}
+
+// Read items of Google Reader API stream one by one, so large exports are not loaded in full
+func loadGoogleReaderItems(r io.Reader, fn func(item *feedReaderItem) error) error {
+	decoder := json.NewDecoder(r)
+
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("cannot read JSON: %s", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return fmt.Errorf("JSON object is expected")
+	}
+
+	for decoder.More() {
+		token, err = decoder.Token()
+		if err != nil {
+			return fmt.Errorf("cannot read JSON: %s", err)
+		}
+
+		if key, _ := token.(string); key != "items" {
+			var skip json.RawMessage
+			if err = decoder.Decode(&skip); err != nil {
+				return fmt.Errorf("cannot read JSON: %s", err)
+			}
+			continue
+		}
+
+		token, err = decoder.Token()
+		if err != nil {
+			return fmt.Errorf("cannot read JSON: %s", err)
+		}
+		if delim, ok := token.(json.Delim); !ok || delim != '[' {
+			return fmt.Errorf("array of items is expected")
+		}
+
+		for decoder.More() {
+			var item googleReaderItem
+			if err = decoder.Decode(&item); err != nil {
+				return fmt.Errorf("cannot unmarshal item: %s", err)
+			}
+
+			if err = fn(item.feedReaderItem()); err != nil {
+				return err
+			}
+		}
+
+		// closing bracket of the items
+		if _, err = decoder.Token(); err != nil {
+			return fmt.Errorf("cannot read JSON: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// Load items from Google Reader API stream `r` and create model Entry for each of them;
+// `source` names the stream in error messages
+func GetEntriesForGoogleReaderReader(config *EntryConfig, source string, r io.Reader) (model.Entries, error) {
+	return getEntriesForFeedReader(config, "Google Reader JSON", source, func(fn func(item *feedReaderItem) error) error {
+		return loadGoogleReaderItems(r, fn)
+	})
+}
+
+// Load items from Google Reader API JSON file specified by filePath and create model Entry for each of them
+func GetEntriesForGoogleReader(config *EntryConfig, filePath string) (model.Entries, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return model.Entries{}, fmt.Errorf("cannot open file: %s", err)
+	}
+	defer file.Close()
+
+	return GetEntriesForGoogleReaderReader(config, filePath, bufio.NewReader(file))
+}
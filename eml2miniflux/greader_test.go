@@ -0,0 +1,152 @@
+package eml2miniflux
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"miniflux.app/crypto"
+	"miniflux.app/model"
+)
+
+// Google Takeout stream: the keys besides `items` are skipped, numbers are written either way
+const testGoogleReaderJson = `{
+  "id": "user/01234567890123456789/state/com.google/starred",
+  "title": "Starred items",
+  "updated": 1672628645,
+  "items": [
+    {
+      "id": "tag:google.com,2005:reader/item/0000000000000001",
+      "title": "Go 1.20 is released",
+      "author": "Robert Griesemer",
+      "published": 1672628645,
+      "crawlTimeMsec": "1672628700000",
+      "categories": [
+        "user/01234567890123456789/state/com.google/read",
+        "user/01234567890123456789/state/com.google/starred",
+        "user/01234567890123456789/label/Go"
+      ],
+      "canonical": [{"href": "https://go.dev/blog/go1.20"}],
+      "alternate": [{"href": "https://go.dev/blog/go1.20?utm_source=rss", "type": "text/html"}],
+      "enclosure": [{"href": "https://go.dev/blog/go1.20.mp3", "type": "audio/mpeg"}],
+      "summary": {"content": "Summary"},
+      "content": {"content": "<p>Go 1.20 is out.</p>"},
+      "origin": {"streamId": "feed/https://go.dev/blog/feed.atom", "title": "The Go Blog", "htmlUrl": "https://go.dev/blog/"}
+    },
+    {
+      "id": "tag:google.com,2005:reader/item/0000000000000002",
+      "title": "Kept unread",
+      "crawlTimeMsec": "1672715045000",
+      "categories": [
+        "user/-/state/com.google/read",
+        "user/-/state/com.google/kept-unread"
+      ],
+      "alternate": [{"href": "https://blog.example.com/kept", "type": "text/html"}],
+      "summary": {"content": "<p>Summary only</p>"},
+      "origin": {"streamId": "feed/https://blog.example.com/rss", "title": "Example"}
+    },
+    {
+      "id": "tag:google.com,2005:reader/item/0000000000000003",
+      "title": "Without link",
+      "published": "1672801445",
+      "content": {"content": "<p>Text</p>"},
+      "origin": {"streamId": "feed/https://go.dev/blog/feed.atom"}
+    },
+    {
+      "id": "tag:google.com,2005:reader/item/0000000000000004",
+      "title": "Unknown feed",
+      "alternate": [{"href": "https://unknown.example.org/post"}],
+      "origin": {"streamId": "feed/https://unknown.example.org/feed"}
+    }
+  ]
+}`
+
+func TestGetEntriesForGoogleReaderReader(t *testing.T) {
+	goFeed := &model.Feed{FeedURL: "https://go.dev/blog/feed.atom"}
+	exampleFeed := &model.Feed{FeedURL: "https://blog.example.com/rss"}
+	feedHelper := newTestFeedHelper(t, "", goFeed, exampleFeed)
+	config := &EntryConfig{FeedHelper: feedHelper, User: &model.User{ID: 1}, Quiet: true}
+
+	entries, err := GetEntriesForGoogleReaderReader(config, "takeout", strings.NewReader(testGoogleReaderJson))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url     string
+		hash    string
+		feed    *model.Feed
+		status  string
+		starred bool
+		tags    string
+		date    time.Time
+		content string
+	}{
+		{
+			url:     "https://go.dev/blog/go1.20",
+			hash:    crypto.Hash("https://go.dev/blog/go1.20"),
+			feed:    goFeed,
+			status:  model.EntryStatusRead,
+			starred: true,
+			tags:    "Go",
+			date:    time.Unix(1672628645, 0),
+			content: "<p>Go 1.20 is out.</p>",
+		},
+		{
+			url:     "https://blog.example.com/kept",
+			hash:    crypto.Hash("https://blog.example.com/kept"),
+			feed:    exampleFeed,
+			status:  model.EntryStatusUnread,
+			date:    time.UnixMilli(1672715045000),
+			content: "<p>Summary only</p>",
+		},
+		{
+			url:     "",
+			hash:    crypto.Hash("tag:google.com,2005:reader/item/0000000000000003"),
+			feed:    goFeed,
+			status:  model.EntryStatusUnread,
+			date:    time.Unix(1672801445, 0),
+			content: "<p>Text</p>",
+		},
+	}
+
+	if len(entries) != len(tests) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(tests))
+	}
+
+	for i, test := range tests {
+		entry := entries[i]
+		if entry.URL != test.url || entry.Hash != test.hash {
+			t.Errorf("item #%d: got URL %q, hash %s", i+1, entry.URL, entry.Hash)
+		}
+		if entry.FeedID != test.feed.ID {
+			t.Errorf("item #%d: got feed %d, want %d", i+1, entry.FeedID, test.feed.ID)
+		}
+		if entry.Status != test.status || entry.Starred != test.starred {
+			t.Errorf("item #%d: got status %s, starred %v", i+1, entry.Status, entry.Starred)
+		}
+		if tags := strings.Join(entry.Tags, " "); tags != test.tags {
+			t.Errorf("item #%d: got tags %q, want %q", i+1, tags, test.tags)
+		}
+		if !entry.Date.Equal(test.date) {
+			t.Errorf("item #%d: got date %s, want %s", i+1, entry.Date, test.date)
+		}
+		if entry.Content != test.content {
+			t.Errorf("item #%d: got content %q", i+1, entry.Content)
+		}
+	}
+
+	if len(entries[0].Enclosures) != 1 || entries[0].Enclosures[0].URL != "https://go.dev/blog/go1.20.mp3" || entries[0].Enclosures[0].MimeType != "audio/mpeg" {
+		t.Errorf("item #1: got enclosures %v", entries[0].Enclosures)
+	}
+}
+
+func TestGetEntriesForGoogleReaderReaderInvalid(t *testing.T) {
+	config := &EntryConfig{FeedHelper: newTestFeedHelper(t, ""), User: &model.User{ID: 1}}
+
+	for _, document := range []string{`[]`, `{"items": {}}`, `{"items": [{"published": "yesterday"}]}`} {
+		if _, err := GetEntriesForGoogleReaderReader(config, "invalid", strings.NewReader(document)); err == nil {
+			t.Errorf("%s: error expected", document)
+		}
+	}
+}
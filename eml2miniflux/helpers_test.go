@@ -0,0 +1,67 @@
+package eml2miniflux
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sg3des/eml"
+	"miniflux.app/model"
+)
+
+// Feed helper with the feeds and the feed map, without database
+func newTestFeedHelper(t *testing.T, feedMap string, feeds ...*model.Feed) *FeedHelper {
+	h := &FeedHelper{
+		feedsUrl:   map[string]*model.Feed{"none": nil},
+		feedsId:    make(map[int64]*model.Feed),
+		feedsTitle: make(map[string]*model.Feed),
+	}
+	for i, feed := range feeds {
+		feed.ID = int64(i + 1)
+		h.feedsUrl[feed.FeedURL] = feed
+		h.feedsId[feed.ID] = feed
+		h.feedsTitle[feed.Title] = feed
+	}
+
+	fileName := filepath.Join(t.TempDir(), "feedmap.txt")
+	if err := os.WriteFile(fileName, []byte(feedMap), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.LoadMap(fileName); err != nil {
+		t.Fatal(err)
+	}
+
+	return h
+}
+
+// Message of Thunderbird feed item linked to the URL
+func testFeedMessage(subject string, url string) []byte {
+	return []byte(strings.Join([]string{
+		"From: Blog <blog@example.com>",
+		"Subject: " + subject,
+		"Date: Wed, 11 May 2016 14:31:59 +0000",
+		"Message-ID: <" + strings.ReplaceAll(subject, " ", ".") + "@example.com>",
+		"Content-Base: " + url,
+		"Content-Type: text/html; charset=UTF-8",
+		"",
+		"<html><body><p>" + subject + "</p></body></html>",
+		"",
+	}, "\r\n"))
+}
+
+// Message with the headers and HTML body
+func testHtmlMessage(t *testing.T, headers []string, body string) *eml.Message {
+	raw := strings.Join(append(append([]string{
+		"From: Blog <blog@example.com>",
+		"Subject: Post",
+		"Date: Wed, 11 May 2016 14:31:59 +0000",
+		"Content-Type: text/html; charset=UTF-8",
+	}, headers...), "", body, ""), "\r\n")
+
+	message, err := eml.Parse([]byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &message
+}
@@ -0,0 +1,317 @@
+package eml2miniflux
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/client"
+	"github.com/sg3des/eml"
+	"miniflux.app/model"
+)
+
+const (
+	imapScheme  = "imap"
+	imapsScheme = "imaps"
+
+	// Mailbox pattern used when the URL has no path: all mailboxes
+	imapDefaultPattern = "*"
+
+	// Amount of fetched messages buffered while the previous ones are converted
+	imapFetchBuffer = 16
+
+	// ImapPasswordEnv is the environment variable holding the password of IMAP user,
+	// so the password does not appear in the command line
+	ImapPasswordEnv = "EML2MINIFLUX_IMAP_PASSWORD"
+)
+
+// IsImapURL determines if the input path is URL of IMAP server, ex.: `imaps://john@mail.example.com/Feeds/*`
+func IsImapURL(path string) bool {
+	u, err := url.Parse(path)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == imapScheme || u.Scheme == imapsScheme
+}
+
+// Parsed URL of IMAP input
+type imapSource struct {
+	addr     string
+	useTLS   bool
+	username string
+	password string
+	// Mailbox pattern of LIST command
+	pattern string
+	// URL of the server without credentials, used to name the mailboxes
+	serverURL string
+}
+
+func parseImapURL(rawUrl string) (*imapSource, error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, fmt.Errorf("wrong IMAP URL: %s", err)
+	}
+
+	source := &imapSource{
+		addr:    u.Host,
+		useTLS:  u.Scheme == imapsScheme,
+		pattern: strings.TrimPrefix(u.Path, "/"),
+	}
+
+	if len(u.Hostname()) == 0 {
+		return nil, fmt.Errorf("IMAP server is not specified")
+	}
+
+	if len(u.Port()) == 0 {
+		if source.useTLS {
+			source.addr = net.JoinHostPort(u.Hostname(), "993")
+		} else {
+			source.addr = net.JoinHostPort(u.Hostname(), "143")
+		}
+	}
+
+	if len(source.pattern) == 0 {
+		source.pattern = imapDefaultPattern
+	}
+
+	if u.User != nil {
+		source.username = u.User.Username()
+		source.password, _ = u.User.Password()
+	}
+
+	source.serverURL = (&url.URL{Scheme: u.Scheme, Host: u.Host}).String()
+
+	return source, nil
+}
+
+// Connect and log in to IMAP server; STARTTLS is used for `imap://` when the server supports it.
+// Credentials are not sent over plain connection unless `insecure` is set.
+func dialImap(source *imapSource, insecure bool) (*client.Client, error) {
+	var cl *client.Client
+
+	host, _, err := net.SplitHostPort(source.addr)
+	if err != nil {
+		return nil, fmt.Errorf("wrong IMAP server address: %s", err)
+	}
+	tlsConfig := &tls.Config{ServerName: host}
+
+	if source.useTLS {
+		cl, err = client.DialTLS(source.addr, tlsConfig)
+	} else {
+		cl, err = client.Dial(source.addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to IMAP server: %s", err)
+	}
+
+	if !source.useTLS {
+		if ok, _ := cl.SupportStartTLS(); ok {
+			err = cl.StartTLS(tlsConfig)
+			if err != nil {
+				cl.Logout()
+				return nil, fmt.Errorf("cannot start TLS: %s", err)
+			}
+		} else if len(source.username) > 0 && !insecure {
+			cl.Logout()
+			return nil, fmt.Errorf("IMAP server does not support STARTTLS, the password would be sent unencrypted; use imaps:// URL, or '-imapinsecure' option to log in anyway")
+		}
+	}
+
+	if len(source.username) > 0 {
+		err = cl.Login(source.username, source.password)
+		if err != nil {
+			cl.Logout()
+			return nil, fmt.Errorf("cannot log in to IMAP server: %s", err)
+		}
+	}
+
+	return cl, nil
+}
+
+// List selectable mailboxes matching the pattern, sorted by name
+func listImapMailboxes(cl *client.Client, pattern string) ([]*imap.MailboxInfo, error) {
+	mailboxes := make(chan *imap.MailboxInfo, imapFetchBuffer)
+	done := make(chan error, 1)
+	go func() {
+		done <- cl.List("", pattern, mailboxes)
+	}()
+
+	var selectable []*imap.MailboxInfo
+	for mailbox := range mailboxes {
+		noSelect := false
+		for _, attr := range mailbox.Attributes {
+			if strings.EqualFold(attr, imap.NoSelectAttr) {
+				noSelect = true
+			}
+		}
+		if !noSelect {
+			selectable = append(selectable, mailbox)
+		}
+	}
+
+	if err := <-done; err != nil {
+		return nil, fmt.Errorf("cannot list mailboxes: %s", err)
+	}
+
+	sort.Slice(selectable, func(i, j int) bool { return selectable[i].Name < selectable[j].Name })
+	return selectable, nil
+}
+
+// Get read and flagged state of the message from IMAP flags. The second value is set
+// when the message is marked as deleted, i.e. it should not be imported.
+func imapMessageInfo(folder string, flags []string) (*MessageInfo, bool) {
+	info := &MessageInfo{Folder: folder, HasFlags: true}
+	deleted := false
+
+	for _, flag := range flags {
+		switch {
+		case strings.EqualFold(flag, imap.SeenFlag):
+			info.Seen = true
+		case strings.EqualFold(flag, imap.FlaggedFlag):
+			info.Flagged = true
+		case strings.EqualFold(flag, imap.DeletedFlag):
+			deleted = true
+		}
+	}
+
+	return info, deleted
+}
+
+// Path of the mailbox with '/' separators, as the folder path of its messages
+func imapFolderPath(mailbox *imap.MailboxInfo) string {
+	if len(mailbox.Delimiter) == 0 || mailbox.Delimiter == "/" {
+		return mailbox.Name
+	}
+	return strings.ReplaceAll(mailbox.Name, mailbox.Delimiter, "/")
+}
+
+// Create entries for all messages of the mailbox; the mailbox is opened read-only,
+// and the messages are fetched with BODY.PEEK, so their flags are not changed
+func (c *entryCollector) addImapMailbox(cl *client.Client, serverURL string, mailbox *imap.MailboxInfo) error {
+	status, err := cl.Select(mailbox.Name, true)
+	if err != nil {
+		return fmt.Errorf("cannot select mailbox: %s", err)
+	}
+
+	if status.Messages == 0 {
+		return nil
+	}
+
+	folder := serverURL + "/" + mailbox.Name
+	section := &imap.BodySectionName{Peek: true}
+	items := []imap.FetchItem{imap.FetchUid, imap.FetchFlags, section.FetchItem()}
+
+	seqset := new(imap.SeqSet)
+	seqset.AddRange(1, status.Messages)
+
+	messages := make(chan *imap.Message, imapFetchBuffer)
+	done := make(chan error, 1)
+	go func() {
+		done <- cl.Fetch(seqset, items, messages)
+	}()
+
+	for msg := range messages {
+		c.countMessage()
+		source := fmt.Sprintf("%s: UID %d", folder, msg.Uid)
+
+		info, deleted := imapMessageInfo(folder, msg.Flags)
+		if deleted {
+			continue
+		}
+		info.FolderPath = imapFolderPath(mailbox)
+
+		body := msg.GetBody(section)
+		if body == nil {
+			reportEntryError(source, fmt.Errorf("message body is not returned by server"), c.config.Quiet)
+			continue
+		}
+
+		raw, err := io.ReadAll(body)
+		if err != nil {
+			reportEntryError(source, fmt.Errorf("cannot read message: %s", err), c.config.Quiet)
+			continue
+		}
+
+		message, err := eml.Parse(raw)
+		if err != nil {
+			reportEntryError(source, fmt.Errorf("cannot parse EML: %s", err), c.config.Quiet)
+			continue
+		}
+
+		c.addMessage(source, &message, info)
+	}
+
+	if err := <-done; err != nil {
+		return fmt.Errorf("cannot fetch messages: %s", err)
+	}
+
+	return nil
+}
+
+// Create entries for messages of all mailboxes matching the pattern.
+// The client may be connected to any server, e.g. to an in-process one.
+func (c *entryCollector) addImap(cl *client.Client, serverURL string, pattern string) error {
+	mailboxes, err := listImapMailboxes(cl, pattern)
+	if err != nil {
+		return err
+	}
+
+	for _, mailbox := range mailboxes {
+		fmt.Fprintf(os.Stdout, "Reading IMAP mailbox: %s\n", mailbox.Name)
+
+		err = c.addImapMailbox(cl, serverURL, mailbox)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error on processing mailbox: %s/%s: %s\n", serverURL, mailbox.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Load messages from IMAP server specified by imapUrl and create model Entry for each of them.
+// The URL defines the server, user and pattern of the mailboxes, ex.: `imaps://john@mail.example.com/Feeds/*`;
+// the password is taken from `ImapPasswordEnv` environment variable, unless the URL has it.
+// `\Seen` and `\Flagged` flags define read status and star of the entries, and messages flagged `\Deleted` are skipped.
+func GetEntriesForIMAP(config *EntryConfig, imapUrl string) (model.Entries, error) {
+	c := newEntryCollector(config)
+
+	source, err := parseImapURL(imapUrl)
+	if err != nil {
+		return c.entries, err
+	}
+
+	if len(source.password) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: password of IMAP URL may be seen by other users of the system; use %s environment variable instead\n", ImapPasswordEnv)
+	} else if len(source.username) > 0 {
+		source.password = os.Getenv(ImapPasswordEnv)
+		if len(source.password) == 0 {
+			return c.entries, fmt.Errorf("password of IMAP user '%s' is not specified in %s environment variable", source.username, ImapPasswordEnv)
+		}
+	}
+
+	cl, err := dialImap(source, config.ImapInsecure)
+	if err != nil {
+		return c.entries, err
+	}
+	defer cl.Logout()
+
+	err = c.addImap(cl, source.serverURL, source.pattern)
+	fmt.Fprintf(os.Stdout, "Reading IMAP completed. Processed messages: %d\n", c.entryCounter)
+
+	return c.entries, err
+}
+
+// RedactImapURL replaces the password of IMAP URL, so the URL may be printed
+func RedactImapURL(imapUrl string) string {
+	u, err := url.Parse(imapUrl)
+	if err != nil {
+		return imapUrl
+	}
+	return u.Redacted()
+}
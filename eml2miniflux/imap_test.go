@@ -0,0 +1,146 @@
+package eml2miniflux
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/backend/memory"
+	"github.com/emersion/go-imap/client"
+	"github.com/emersion/go-imap/server"
+	"miniflux.app/model"
+)
+
+// Start IMAP server with memory backend on the loopback interface; it holds messages
+// of the mailboxes, by mailbox name, with the flags
+func startTestImapServer(t *testing.T, mailboxes map[string][]testImapMessage) string {
+	s := server.New(memory.New())
+	s.AllowInsecureAuth = true
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Serve(l)
+	t.Cleanup(func() { s.Close() })
+
+	cl, err := client.Dial(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cl.Logout()
+
+	if err := cl.Login("username", "password"); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, messages := range mailboxes {
+		if err := cl.Create(name); err != nil {
+			t.Fatal(err)
+		}
+		for _, message := range messages {
+			err := cl.Append(name, message.flags, time.Now(), bytes.NewBuffer(message.raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	return l.Addr().String()
+}
+
+type testImapMessage struct {
+	raw   []byte
+	flags []string
+}
+
+func TestGetEntriesForIMAP(t *testing.T) {
+	addr := startTestImapServer(t, map[string][]testImapMessage{
+		"Feeds/Go": {
+			{raw: testFeedMessage("Unread", "https://go.dev/blog/unread")},
+			{raw: testFeedMessage("Read", "https://go.dev/blog/read"), flags: []string{imap.SeenFlag}},
+			{raw: testFeedMessage("Starred", "https://go.dev/blog/starred"), flags: []string{imap.SeenFlag, imap.FlaggedFlag}},
+			{raw: testFeedMessage("Deleted", "https://go.dev/blog/deleted"), flags: []string{imap.DeletedFlag}},
+		},
+		"Feeds/XKCD": {
+			{raw: testFeedMessage("Comic", "https://xkcd.com/1/"), flags: []string{imap.FlaggedFlag}},
+			{raw: testFeedMessage("Ignored", "https://example.org/ignored")},
+		},
+		"Feeds/Misc": {
+			{raw: testFeedMessage("Routed by folder", "https://example.org/misc")},
+		},
+		"Other": {
+			{raw: testFeedMessage("Not listed", "https://go.dev/blog/other")},
+		},
+	})
+
+	feedHelper := newTestFeedHelper(t, `
+go.dev => https://go.dev/blog/feed.atom
+xkcd.com => https://xkcd.com/rss.xml
+example.org => none
+folder:prefix:Feeds/Misc => https://example.com/misc.xml
+`,
+		&model.Feed{FeedURL: "https://go.dev/blog/feed.atom", Title: "Go Blog"},
+		&model.Feed{FeedURL: "https://xkcd.com/rss.xml", Title: "xkcd"},
+		&model.Feed{FeedURL: "https://example.com/misc.xml", Title: "Misc"},
+	)
+	config := &EntryConfig{FeedHelper: feedHelper, User: &model.User{ID: 1}, Quiet: true, ImapInsecure: true}
+
+	t.Setenv(ImapPasswordEnv, "password")
+	entries, err := GetEntriesForIMAP(config, fmt.Sprintf("imap://username@%s/Feeds/*", addr))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]struct {
+		feedUrl string
+		status  string
+		starred bool
+	}{
+		"https://go.dev/blog/unread":  {feedUrl: "https://go.dev/blog/feed.atom", status: model.EntryStatusUnread},
+		"https://go.dev/blog/read":    {feedUrl: "https://go.dev/blog/feed.atom", status: model.EntryStatusRead},
+		"https://go.dev/blog/starred": {feedUrl: "https://go.dev/blog/feed.atom", status: model.EntryStatusRead, starred: true},
+		"https://xkcd.com/1/":         {feedUrl: "https://xkcd.com/rss.xml", status: model.EntryStatusUnread, starred: true},
+		// the folder rule is applied before the rules of URL
+		"https://example.org/misc": {feedUrl: "https://example.com/misc.xml", status: model.EntryStatusUnread},
+	}
+
+	if len(entries) != len(want) {
+		t.Errorf("got %d entries, want %d", len(entries), len(want))
+	}
+	for _, entry := range entries {
+		w, ok := want[entry.URL]
+		if !ok {
+			t.Errorf("unexpected entry: %s", entry.URL)
+			continue
+		}
+		if entry.FeedID != feedHelper.FeedByURL(w.feedUrl).ID || entry.UserID != 1 {
+			t.Errorf("%s: got feed %d, want %s", entry.URL, entry.FeedID, w.feedUrl)
+		}
+		if entry.Status != w.status || entry.Starred != w.starred {
+			t.Errorf("%s: got status %s, starred %v; want %s, %v", entry.URL, entry.Status, entry.Starred, w.status, w.starred)
+		}
+	}
+}
+
+func TestGetEntriesForIMAPRefusesPlainLogin(t *testing.T) {
+	addr := startTestImapServer(t, nil)
+	config := &EntryConfig{User: &model.User{ID: 1}, Quiet: true}
+
+	t.Setenv(ImapPasswordEnv, "password")
+	_, err := GetEntriesForIMAP(config, fmt.Sprintf("imap://username@%s/", addr))
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Errorf("expected refusal to log in without TLS, got: %v", err)
+	}
+
+	t.Setenv(ImapPasswordEnv, "")
+	config.ImapInsecure = true
+	_, err = GetEntriesForIMAP(config, fmt.Sprintf("imap://username@%s/", addr))
+	if err == nil || !strings.Contains(err.Error(), ImapPasswordEnv) {
+		t.Errorf("expected missing password error, got: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package eml2miniflux
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"miniflux.app/model"
+)
+
+// IsLifereaDatabase determines if a file represented by `filePath`
+// is Liferea database, i.e. SQLite database with `items`, `item_metadata` and `subscription` tables
+func IsLifereaDatabase(filePath string) bool {
+	return isSqliteWithTables(filePath, "items", "item_metadata", "subscription")
+}
+
+// Parse Liferea enclosure metadata, ex.: `enc:0:audio/mpeg:12345:https://example.com/a.mp3`;
+// older versions keep the URL only
+func parseLifereaEnclosure(value string) (string, string) {
+	if !strings.HasPrefix(value, "enc:") {
+		return value, ""
+	}
+
+	// enc:<downloaded>:<mime type>:<size>:<URL>
+	parts := strings.SplitN(value, ":", 5)
+	if len(parts) < 5 {
+		return "", ""
+	}
+
+	return parts[4], parts[2]
+}
+
+// Load items of Liferea database. Items copied into news bins refer to their original feed
+// by `parent_node_id`; comments are skipped.
+func loadLifereaItems(db *sql.DB, fn func(item *feedReaderItem) error) error {
+	rows, err := db.Query(`
+		SELECT
+			COALESCE(i.source_id, ''), COALESCE(i.title, ''), COALESCE(i.source, ''), COALESCE(s.source, ''),
+			COALESCE(i.date, 0), COALESCE(i.description, ''), COALESCE(i.read, 0) != 0, COALESCE(i.marked, 0) != 0,
+			COALESCE((SELECT m.value FROM item_metadata m WHERE m.item_id = i.item_id AND m.key = 'author' ORDER BY m.nr LIMIT 1), ''),
+			COALESCE((SELECT m.value FROM item_metadata m WHERE m.item_id = i.item_id AND m.key = 'enclosure' ORDER BY m.nr LIMIT 1), '')
+		FROM items i
+		LEFT JOIN subscription s ON s.node_id = COALESCE(NULLIF(i.parent_node_id, ''), i.node_id)
+		WHERE COALESCE(i.comment, 0) = 0
+		ORDER BY i.item_id`)
+	if err != nil {
+		return fmt.Errorf("cannot query items: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item feedReaderItem
+		var date int64
+		var enclosure string
+
+		err = rows.Scan(&item.guid, &item.title, &item.url, &item.feedUrl,
+			&date, &item.content, &item.read, &item.starred,
+			&item.author, &enclosure)
+		if err != nil {
+			return fmt.Errorf("cannot read item: %s", err)
+		}
+
+		if date > 0 {
+			item.published = time.Unix(date, 0)
+		}
+		item.enclosureUrl, item.enclosureType = parseLifereaEnclosure(enclosure)
+
+		err = fn(&item)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Load items from Liferea database specified by dbPath and create model Entry for each of them
+func GetEntriesForLiferea(config *EntryConfig, dbPath string) (model.Entries, error) {
+	return getEntriesForFeedReaderDb(config, "Liferea database", dbPath, loadLifereaItems)
+}
@@ -0,0 +1,95 @@
+package eml2miniflux
+
+import (
+	"testing"
+	"time"
+
+	"miniflux.app/crypto"
+	"miniflux.app/model"
+)
+
+func TestParseLifereaEnclosure(t *testing.T) {
+	tests := []struct {
+		value    string
+		wantUrl  string
+		wantType string
+	}{
+		{"enc:0:audio/mpeg:12345:https://example.com/a.mp3", "https://example.com/a.mp3", "audio/mpeg"},
+		{"enc:1::0:https://example.com/b:c.mp3", "https://example.com/b:c.mp3", ""},
+		{"https://example.com/a.mp3", "https://example.com/a.mp3", ""},
+		{"enc:0:audio/mpeg", "", ""},
+	}
+
+	for _, test := range tests {
+		url, mimeType := parseLifereaEnclosure(test.value)
+		if url != test.wantUrl || mimeType != test.wantType {
+			t.Errorf("parseLifereaEnclosure(%q) = %q, %q, want %q, %q", test.value, url, mimeType, test.wantUrl, test.wantType)
+		}
+	}
+}
+
+func TestGetEntriesForLiferea(t *testing.T) {
+	dbPath := testSqliteDatabase(t,
+		`CREATE TABLE items (item_id INTEGER PRIMARY KEY, parent_item_id INTEGER, node_id TEXT, parent_node_id TEXT,
+			title TEXT, read INTEGER, updated INTEGER, popup INTEGER, marked INTEGER, source TEXT, source_id TEXT,
+			valid_guid INTEGER, description TEXT, date INTEGER, comment_feed_id TEXT, comment INTEGER)`,
+		`CREATE TABLE item_metadata (item_id INTEGER, nr INTEGER, key TEXT, value TEXT)`,
+		`CREATE TABLE subscription (node_id TEXT PRIMARY KEY, source TEXT, orig_source TEXT, filter_cmd TEXT,
+			update_interval INTEGER, default_interval INTEGER, discontinued INTEGER, available INTEGER)`,
+		`INSERT INTO subscription (node_id, source) VALUES ('go', 'https://go.dev/blog/feed.atom'),
+			('example', 'https://blog.example.com/rss'), ('unknown', 'https://unknown.example.org/feed')`,
+		// read and flagged item with author and enclosure
+		`INSERT INTO items (item_id, node_id, parent_node_id, title, read, marked, source, source_id, description, date, comment)
+			VALUES (1, 'go', '', 'Go 1.20 is released', 1, 1, 'https://go.dev/blog/go1.20', 'tag:go.dev,2023:go1.20', '<p>Go 1.20 is out.</p>', 1672628645, 0)`,
+		`INSERT INTO item_metadata (item_id, nr, key, value) VALUES (1, 1, 'author', 'Robert Griesemer'),
+			(1, 2, 'enclosure', 'enc:0:audio/mpeg:12345:https://go.dev/blog/go1.20.mp3')`,
+		// copy in a news bin refers to the feed of the original item
+		`INSERT INTO items (item_id, node_id, parent_node_id, title, read, marked, source, description, date)
+			VALUES (2, 'bin', 'example', 'Kept', 0, 0, 'https://blog.example.com/kept', '<p>Text</p>', 1672715045)`,
+		`INSERT INTO items (item_id, node_id, parent_node_id, title, read, source, date, comment)
+			VALUES (3, 'go', '', 'Comment', 0, 'https://go.dev/blog/go1.20#comment', 1672715045, 1)`,
+		`INSERT INTO items (item_id, node_id, title, read, source, date)
+			VALUES (4, 'unknown', 'Unknown feed', 0, 'https://unknown.example.org/post', 1672715045)`)
+
+	if !IsLifereaDatabase(dbPath) {
+		t.Fatalf("%s is not detected as Liferea database", dbPath)
+	}
+
+	goFeed := &model.Feed{FeedURL: "https://go.dev/blog/feed.atom"}
+	exampleFeed := &model.Feed{FeedURL: "https://blog.example.com/rss"}
+	feedHelper := newTestFeedHelper(t, "", goFeed, exampleFeed)
+	config := &EntryConfig{FeedHelper: feedHelper, User: &model.User{ID: 1}, Quiet: true}
+
+	entries, err := GetEntriesForLiferea(config, dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+
+	first := entries[0]
+	if first.URL != "https://go.dev/blog/go1.20" || first.Hash != crypto.Hash("tag:go.dev,2023:go1.20") || first.FeedID != goFeed.ID {
+		t.Errorf("item #1: got URL %q, hash %s, feed %d", first.URL, first.Hash, first.FeedID)
+	}
+	if first.Status != model.EntryStatusRead || !first.Starred {
+		t.Errorf("item #1: got status %s, starred %v", first.Status, first.Starred)
+	}
+	if first.Author != "Robert Griesemer" || !first.Date.Equal(time.Unix(1672628645, 0)) {
+		t.Errorf("item #1: got author %q, date %s", first.Author, first.Date)
+	}
+	if len(first.Enclosures) != 1 || first.Enclosures[0].URL != "https://go.dev/blog/go1.20.mp3" || first.Enclosures[0].MimeType != "audio/mpeg" {
+		t.Errorf("item #1: got enclosures %v", first.Enclosures)
+	}
+
+	second := entries[1]
+	if second.URL != "https://blog.example.com/kept" || second.FeedID != exampleFeed.ID {
+		t.Errorf("item #2: got URL %q, feed %d", second.URL, second.FeedID)
+	}
+	if second.Status != model.EntryStatusUnread || second.Starred {
+		t.Errorf("item #2: got status %s, starred %v", second.Status, second.Starred)
+	}
+	if second.Content != "<p>Text</p>" || len(second.Enclosures) != 0 {
+		t.Errorf("item #2: got content %q, enclosures %v", second.Content, second.Enclosures)
+	}
+}
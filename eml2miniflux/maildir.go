@@ -0,0 +1,99 @@
+package eml2miniflux
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/a-ilin/eml2miniflux/util"
+	"miniflux.app/model"
+)
+
+var (
+	// Info part of Maildir file name: `<unique>:2,<flags>`
+	// Some tools use `!` or `;` as separator, since `:` is not allowed on Windows
+	maildirInfoRx = regexp.MustCompile(`[:!;]2,([A-Za-z]*)$`)
+)
+
+// IsMaildir determines if a directory represented by `dirPath`
+// is a Maildir, i.e. it has `cur` and `new` subdirectories
+func IsMaildir(dirPath string) bool {
+	for _, sub := range []string{"cur", "new"} {
+		isDir, err := util.IsDirectory(filepath.Join(dirPath, sub))
+		if err != nil || !isDir {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Get message flags from Maildir file name. The second value is set
+// when the message is marked as trashed, i.e. it should not be imported.
+func maildirMessageInfo(path string) (*MessageInfo, bool) {
+	// Maildir folder contains `cur` and `new` subdirectories
+	info := &MessageInfo{Folder: filepath.Dir(filepath.Dir(path))}
+
+	if filepath.Base(filepath.Dir(path)) == "new" {
+		// messages under `new` are not seen by any client yet
+		info.HasFlags = true
+		return info, false
+	}
+
+	match := maildirInfoRx.FindStringSubmatch(filepath.Base(path))
+	if len(match) < 2 {
+		// no info part, ex. Thunderbird maildir store keeps flags in the message headers
+		return info, false
+	}
+
+	flags := match[1]
+	info.HasFlags = true
+	info.Seen = strings.ContainsRune(flags, 'S')
+	info.Flagged = strings.ContainsRune(flags, 'F')
+
+	return info, strings.ContainsRune(flags, 'T')
+}
+
+// Recursively traverse Maildir (including Maildir++ subfolders) and load messages under `cur` and `new`
+func (c *entryCollector) maildirWalkFunc() filepath.WalkFunc {
+	return func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "FS Error: %s: %s\n", path, err)
+			return nil
+		}
+
+		if info.IsDir() {
+			if info.Name() == "tmp" && IsMaildir(filepath.Dir(path)) {
+				// messages being delivered
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		dirName := filepath.Base(filepath.Dir(path))
+		if (dirName != "cur" && dirName != "new") || strings.HasPrefix(info.Name(), ".") {
+			return nil
+		}
+
+		messageInfo, trashed := maildirMessageInfo(path)
+		if !trashed {
+			c.addFile(path, messageInfo)
+		}
+
+		return nil
+	}
+}
+
+// Load messages from the Maildir specified by maildirPath and create model Entry for each of them.
+// Maildir flags `S` (seen) and `F` (flagged) are applied to the entry status and star.
+func GetEntriesForMaildir(config *EntryConfig, maildirPath string) (model.Entries, error) {
+	c := newEntryCollector(config)
+	c.root = maildirPath
+
+	err := filepath.Walk(maildirPath, c.maildirWalkFunc())
+	fmt.Fprintf(os.Stdout, "Reading Maildir completed. Processed files: %d\n", c.entryCounter)
+
+	return c.entries, err
+}
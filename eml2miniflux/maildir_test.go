@@ -0,0 +1,119 @@
+package eml2miniflux
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"miniflux.app/model"
+)
+
+func TestMaildirMessageInfo(t *testing.T) {
+	dir := filepath.Join("mail", "Feeds")
+	tests := []struct {
+		name     string
+		hasFlags bool
+		seen     bool
+		flagged  bool
+		trashed  bool
+	}{
+		{name: "cur/1.host:2,", hasFlags: true},
+		{name: "cur/2.host:2,S", hasFlags: true, seen: true},
+		{name: "cur/3.host:2,F", hasFlags: true, flagged: true},
+		{name: "cur/4.host:2,FRS", hasFlags: true, seen: true, flagged: true},
+		{name: "cur/5.host:2,ST", hasFlags: true, seen: true, trashed: true},
+		{name: "cur/6.host!2,S", hasFlags: true, seen: true},
+		{name: "cur/7.host;2,F", hasFlags: true, flagged: true},
+		// lowercase letters are experimental flags, the message is not seen
+		{name: "cur/8.host:2,fs", hasFlags: true},
+		{name: "cur/9.host:2,S,F"},
+		{name: "cur/10.host"},
+		{name: "cur/11.host:1,S"},
+		{name: "new/12.host", hasFlags: true},
+	}
+
+	for _, test := range tests {
+		info, trashed := maildirMessageInfo(filepath.Join(dir, filepath.FromSlash(test.name)))
+		if info.HasFlags != test.hasFlags || info.Seen != test.seen || info.Flagged != test.flagged || trashed != test.trashed {
+			t.Errorf("%s: got flags %v, seen %v, flagged %v, trashed %v", test.name, info.HasFlags, info.Seen, info.Flagged, trashed)
+		}
+		if info.Folder != dir {
+			t.Errorf("%s: got folder %q, want %q", test.name, info.Folder, dir)
+		}
+	}
+}
+
+func TestGetEntriesForMaildir(t *testing.T) {
+	// the Maildir is read on its own, and as a folder of a directory input
+	root := t.TempDir()
+	dir := filepath.Join(root, "Feeds")
+	files := map[string][]byte{
+		"cur/1.host:2,S":        testFeedMessage("Seen", "https://example.com/seen"),
+		"cur/2.host:2,F":        testFeedMessage("Flagged", "https://example.com/flagged"),
+		"cur/3.host:2,ST":       testFeedMessage("Trashed", "https://example.com/trashed"),
+		"cur/.hidden":           testFeedMessage("Hidden", "https://example.com/hidden"),
+		"new/4.host":            testFeedMessage("New", "https://example.com/new"),
+		"tmp/5.host":            testFeedMessage("Delivered", "https://example.com/tmp"),
+		".Blogs/cur/6.host:2,S": testFeedMessage("Subfolder", "https://example.com/subfolder"),
+		".Blogs/new/":           nil,
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if strings.HasSuffix(name, "/") {
+			if err := os.MkdirAll(path, 0777); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !IsMaildir(dir) {
+		t.Fatalf("%s is not Maildir", dir)
+	}
+
+	feed := &model.Feed{FeedURL: "https://example.com/feed.xml"}
+	config := &EntryConfig{FeedHelper: newTestFeedHelper(t, "", feed), User: &model.User{ID: 1}, DefaultFeed: feed, Quiet: true}
+
+	// messages under `tmp`, hidden and trashed ones are skipped
+	want := []string{
+		"https://example.com/flagged unread starred",
+		"https://example.com/new unread -",
+		"https://example.com/seen read -",
+		"https://example.com/subfolder read -",
+	}
+
+	for _, input := range []string{dir, root} {
+		var entries model.Entries
+		var err error
+		if input == dir {
+			entries, err = GetEntriesForMaildir(config, input)
+		} else {
+			entries, err = GetEntriesForEML(config, input)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, entry := range entries {
+			starred := "-"
+			if entry.Starred {
+				starred = "starred"
+			}
+			got = append(got, entry.URL+" "+entry.Status+" "+starred)
+		}
+		sort.Strings(got)
+
+		if strings.Join(got, ", ") != strings.Join(want, ", ") {
+			t.Errorf("%s: got entries %v, want %v", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package eml2miniflux
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sg3des/eml"
+	"miniflux.app/model"
+)
+
+var (
+	mboxFromLine = []byte("From ")
+)
+
+// IsMboxFile determines if a file represented by `filePath`
+// starts with mbox separator line
+func IsMboxFile(filePath string) (bool, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	header := make([]byte, len(mboxFromLine))
+	_, err = io.ReadFull(file, header)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(header, mboxFromLine), nil
+}
+
+func isEmptyLine(line []byte) bool {
+	return len(bytes.TrimRight(line, "\r\n")) == 0
+}
+
+// Remove one level of `>From ` quoting; covers both mboxo and mboxrd flavours
+func unquoteMboxLine(line []byte) []byte {
+	if len(line) > 0 && line[0] == '>' && bytes.HasPrefix(bytes.TrimLeft(line, ">"), mboxFromLine) {
+		return line[1:]
+	}
+	return line
+}
+
+// Remove the empty line which separates the message from the next `From ` line
+func trimMboxMessage(message []byte) []byte {
+	if bytes.HasSuffix(message, []byte("\r\n\r\n")) {
+		return message[:len(message)-2]
+	}
+	if bytes.HasSuffix(message, []byte("\n\n")) {
+		return message[:len(message)-1]
+	}
+	return message
+}
+
+// Split mbox stream into raw messages and call `fn` for each of them
+func readMbox(r io.Reader, fn func(raw []byte) error) error {
+	reader := bufio.NewReader(r)
+
+	var message []byte
+	inMessage := false
+	prevEmpty := true
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			if prevEmpty && bytes.HasPrefix(line, mboxFromLine) {
+				if inMessage {
+					if fnErr := fn(trimMboxMessage(message)); fnErr != nil {
+						return fnErr
+					}
+				}
+				// new buffer for each message, as parsed message refers to it
+				message = nil
+				inMessage = true
+			} else if inMessage {
+				message = append(message, unquoteMboxLine(line)...)
+			}
+			prevEmpty = isEmptyLine(line)
+		}
+
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if inMessage {
+		return fn(trimMboxMessage(message))
+	}
+
+	return nil
+}
+
+// Create entries for all messages of the mbox stream; `source` names the mbox in error messages
+func (c *entryCollector) addMboxStream(source string, info *MessageInfo, r io.Reader) error {
+	messageNum := 0
+	return readMbox(r, func(raw []byte) error {
+		messageNum++
+		c.countMessage()
+
+		messageSource := fmt.Sprintf("%s: message #%d", source, messageNum)
+
+		message, err := eml.Parse(raw)
+		if err != nil {
+			reportEntryError(messageSource, fmt.Errorf("cannot parse EML: %s", err), c.config.Quiet)
+			return nil
+		}
+
+		if isExpungedMessage(&message) {
+			return nil
+		}
+
+		messageInfo := *info
+		c.addMessage(messageSource, &message, &messageInfo)
+		return nil
+	})
+}
+
+// Create entries for all messages of the mbox file
+func (c *entryCollector) addMbox(mboxPath string) error {
+	file, err := os.Open(mboxPath)
+	if err != nil {
+		return fmt.Errorf("cannot open mbox: %s", err)
+	}
+	defer file.Close()
+
+	err = c.addMboxStream(mboxPath, &MessageInfo{Folder: mboxPath}, file)
+	if err != nil {
+		return fmt.Errorf("cannot read mbox: %s: %s", mboxPath, err)
+	}
+
+	return nil
+}
+
+// Load messages from the mbox file specified by mboxPath and create model Entry for each of them
+func GetEntriesForMbox(config *EntryConfig, mboxPath string) (model.Entries, error) {
+	c := newEntryCollector(config)
+	c.root = mboxPath
+
+	err := c.addMbox(mboxPath)
+	fmt.Fprintf(os.Stdout, "Reading mbox completed. Processed messages: %d\n", c.entryCounter)
+
+	return c.entries, err
+}
+
+// Load messages from the mbox stream `r` and create model Entry for each of them;
+// `source` names the stream in error messages
+func GetEntriesForMboxReader(config *EntryConfig, source string, r io.Reader) (model.Entries, error) {
+	c := newEntryCollector(config)
+
+	err := c.addMboxStream(source, &MessageInfo{}, r)
+	if err != nil {
+		err = fmt.Errorf("cannot read mbox: %s: %s", source, err)
+	}
+	fmt.Fprintf(os.Stdout, "Reading mbox completed. Processed messages: %d\n", c.entryCounter)
+
+	return c.entries, err
+}
@@ -0,0 +1,80 @@
+package eml2miniflux
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnquoteMboxLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{line: "From here\n", want: "From here\n"},
+		{line: ">From here\n", want: "From here\n"},
+		{line: ">>From here\n", want: ">From here\n"},
+		{line: "> From here\n", want: "> From here\n"},
+		{line: ">quoted reply\n", want: ">quoted reply\n"},
+		{line: ">Fromage\n", want: ">Fromage\n"},
+		{line: "", want: ""},
+	}
+
+	for _, test := range tests {
+		if got := string(unquoteMboxLine([]byte(test.line))); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.line, got, test.want)
+		}
+	}
+}
+
+func TestReadMbox(t *testing.T) {
+	tests := []struct {
+		name string
+		mbox string
+		want []string
+	}{
+		{
+			name: "empty",
+			mbox: "",
+		},
+		{
+			name: "single",
+			mbox: "From a@example.com Mon Jan  1 00:00:00 2024\nSubject: 1\n\nbody\n",
+			want: []string{"Subject: 1\n\nbody\n"},
+		},
+		{
+			name: "separated by empty line",
+			mbox: "From a\nSubject: 1\n\nbody\n\nFrom b\nSubject: 2\n\nbody\n",
+			want: []string{"Subject: 1\n\nbody\n", "Subject: 2\n\nbody\n"},
+		},
+		{
+			name: "From line inside body without empty line",
+			mbox: "From a\nSubject: 1\n\nbody\nFrom here\n",
+			want: []string{"Subject: 1\n\nbody\nFrom here\n"},
+		},
+		{
+			name: "quoted From lines",
+			mbox: "From a\nSubject: 1\n\n>From here\n>>From there\n",
+			want: []string{"Subject: 1\n\nFrom here\n>From there\n"},
+		},
+		{
+			name: "CRLF",
+			mbox: "From a\r\nSubject: 1\r\n\r\nbody\r\n\r\nFrom b\r\nSubject: 2\r\n",
+			want: []string{"Subject: 1\r\n\r\nbody\r\n", "Subject: 2\r\n"},
+		},
+	}
+
+	for _, test := range tests {
+		var got []string
+		err := readMbox(strings.NewReader(test.mbox), func(raw []byte) error {
+			got = append(got, string(raw))
+			return nil
+		})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		if strings.Join(got, "|") != strings.Join(test.want, "|") || len(got) != len(test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package eml2miniflux
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"miniflux.app/model"
+)
+
+const (
+	mhSequencesFile = ".mh_sequences"
+
+	mhSequenceUnseen  = "unseen"
+	mhSequenceFlagged = "flagged"
+)
+
+// Range of message numbers of MH sequence, inclusive
+type mhRange struct {
+	from int
+	to   int
+}
+
+// Message numbers of MH sequence, kept as ranges since a range may span many messages
+type mhSequence []mhRange
+
+// Message sequences of MH folder: name => message numbers
+type mhSequences map[string]mhSequence
+
+// Determine if the sequence holds the message number
+func (s mhSequence) contains(num int) bool {
+	for _, r := range s {
+		if num >= r.from && num <= r.to {
+			return true
+		}
+	}
+	return false
+}
+
+func isMHMessageName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+
+	for _, r := range name {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Determine if the items of the directory include EML files
+func hasEMLFiles(items []os.DirEntry) bool {
+	for _, item := range items {
+		if !item.IsDir() && strings.HasSuffix(strings.ToLower(item.Name()), ".eml") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Directory with `.mh_sequences` is MH folder; otherwise it should have numbered files and no EML files,
+// so a directory of EML files, which happen to have numbered files as well, is imported as before
+func hasMHMessages(dirPath string) bool {
+	if _, err := os.Stat(filepath.Join(dirPath, mhSequencesFile)); err == nil {
+		return true
+	}
+
+	items, err := os.ReadDir(dirPath)
+	if err != nil || hasEMLFiles(items) {
+		return false
+	}
+
+	for _, item := range items {
+		if !item.IsDir() && isMHMessageName(item.Name()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsMHFolder determines if a directory represented by `dirPath`,
+// or any of its immediate subdirectories, contains MH messages.
+// The directory holding EML files is not MH folder unless it has `.mh_sequences`.
+func IsMHFolder(dirPath string) bool {
+	if hasMHMessages(dirPath) {
+		return true
+	}
+
+	items, err := os.ReadDir(dirPath)
+	if err != nil || hasEMLFiles(items) {
+		return false
+	}
+
+	for _, item := range items {
+		if item.IsDir() && hasMHMessages(filepath.Join(dirPath, item.Name())) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Parse a sequence line value, ex.: `1-3 5 8-9`
+func parseMHSequence(value string) (mhSequence, error) {
+	var sequence mhSequence
+
+	for _, r := range strings.Fields(value) {
+		first, last, isRange := strings.Cut(r, "-")
+
+		from, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("wrong message number: %s", r)
+		}
+
+		to := from
+		if isRange {
+			to, err = strconv.Atoi(last)
+			if err != nil {
+				return nil, fmt.Errorf("wrong message range: %s", r)
+			}
+		}
+
+		if from < 1 || to < from {
+			return nil, fmt.Errorf("wrong message range: %s", r)
+		}
+
+		sequence = append(sequence, mhRange{from: from, to: to})
+	}
+
+	return sequence, nil
+}
+
+// Load `.mh_sequences` of the folder; nil is returned when the file does not exist
+func loadMHSequences(dirPath string) (mhSequences, error) {
+	file, err := os.Open(filepath.Join(dirPath, mhSequencesFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sequences := make(mhSequences)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		name, value, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+
+		numbers, err := parseMHSequence(value)
+		if err != nil {
+			return nil, fmt.Errorf("sequence %s: %s", name, err)
+		}
+		sequences[strings.TrimSpace(name)] = numbers
+	}
+
+	return sequences, scanner.Err()
+}
+
+// Get message flags from MH sequences
+func mhMessageInfo(sequences mhSequences, path string) *MessageInfo {
+	info := &MessageInfo{Folder: filepath.Dir(path)}
+	if sequences == nil {
+		return info
+	}
+
+	num, _ := strconv.Atoi(filepath.Base(path))
+
+	info.HasFlags = true
+	info.Seen = !sequences[mhSequenceUnseen].contains(num)
+	info.Flagged = sequences[mhSequenceFlagged].contains(num)
+
+	return info
+}
+
+// MH folders met while walking directories, with their sequences. Each directory is MH folder
+// or not on its own, so EML files and mbox files of the other directories are still loaded.
+type mhFolders map[string]mhSequences
+
+// Remember the directory when it is MH folder
+func (f mhFolders) addDirectory(dirPath string) {
+	if !hasMHMessages(dirPath) {
+		return
+	}
+
+	sequences, err := loadMHSequences(dirPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot read MH sequences: %s: %s\n", dirPath, err)
+	}
+	f[filepath.Clean(dirPath)] = sequences
+}
+
+// Info of the message when the file is a message of MH folder, nil otherwise
+func (f mhFolders) messageInfo(path string) *MessageInfo {
+	sequences, ok := f[filepath.Dir(path)]
+	if !ok || !isMHMessageName(filepath.Base(path)) {
+		return nil
+	}
+	return mhMessageInfo(sequences, path)
+}
+
+// Load messages from the MH folder specified by mhPath and create model Entry for each of them.
+// Sequences `unseen` and `flagged` are applied to the entry status and star.
+// Directories of the tree which are not MH folders are read as directories of EML files.
+func GetEntriesForMH(config *EntryConfig, mhPath string) (model.Entries, error) {
+	c := newEntryCollector(config)
+	c.root = mhPath
+
+	err := filepath.Walk(mhPath, c.emlWalkFunc())
+	fmt.Fprintf(os.Stdout, "Reading MH completed. Processed files: %d\n", c.entryCounter)
+
+	return c.entries, err
+}
@@ -0,0 +1,159 @@
+package eml2miniflux
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"miniflux.app/model"
+)
+
+func TestParseMHSequence(t *testing.T) {
+	tests := []struct {
+		value    string
+		contains []int
+		excludes []int
+		wantErr  bool
+	}{
+		{value: "", excludes: []int{1}},
+		{value: " 5", contains: []int{5}, excludes: []int{4, 6}},
+		{value: " 1-3 5 8-9", contains: []int{1, 2, 3, 5, 8, 9}, excludes: []int{0, 4, 6, 7, 10}},
+		{value: " 1-2000000000", contains: []int{1, 1000000, 2000000000}, excludes: []int{2000000001}},
+		{value: " 3-1", wantErr: true},
+		{value: " 0", wantErr: true},
+		{value: " 1-", wantErr: true},
+		{value: " a", wantErr: true},
+	}
+
+	for _, test := range tests {
+		sequence, err := parseMHSequence(test.value)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%q: expected error", test.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", test.value, err)
+			continue
+		}
+		for _, num := range test.contains {
+			if !sequence.contains(num) {
+				t.Errorf("%q: expected to contain %d", test.value, num)
+			}
+		}
+		for _, num := range test.excludes {
+			if sequence.contains(num) {
+				t.Errorf("%q: expected not to contain %d", test.value, num)
+			}
+		}
+	}
+}
+
+func TestMHMessageInfo(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, mhSequencesFile), []byte("unseen: 2-3\nflagged: 3\n"), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sequences, err := loadMHSequences(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		seen    bool
+		flagged bool
+	}{
+		{name: "1", seen: true},
+		{name: "2"},
+		{name: "3", flagged: true},
+	}
+
+	for _, test := range tests {
+		info := mhMessageInfo(sequences, filepath.Join(dir, test.name))
+		if !info.HasFlags || info.Seen != test.seen || info.Flagged != test.flagged {
+			t.Errorf("%s: got seen %v, flagged %v", test.name, info.Seen, info.Flagged)
+		}
+	}
+}
+
+func TestIsMHFolder(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  bool
+	}{
+		{name: "numbered", files: []string{"1", "2"}, want: true},
+		{name: "sequences", files: []string{mhSequencesFile}, want: true},
+		{name: "subfolder", files: []string{"inbox/1"}, want: true},
+		{name: "emls", files: []string{"1.eml", "2.eml"}},
+		{name: "emls with numbered", files: []string{"1.eml", "2"}},
+		{name: "emls with numbered subfolder", files: []string{"1.eml", "sub/2"}},
+		{name: "emls with sequences", files: []string{"1.eml", "2", mhSequencesFile}, want: true},
+	}
+
+	for _, test := range tests {
+		dir := t.TempDir()
+		for _, file := range test.files {
+			path := filepath.Join(dir, file)
+			if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, nil, 0666); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if got := IsMHFolder(dir); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestGetEntriesForMHMixedTree(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"inbox/1":           testFeedMessage("MH first", "https://example.com/mh/1"),
+		"inbox/2":           testFeedMessage("MH second", "https://example.com/mh/2"),
+		"saved/a.eml":       testFeedMessage("Saved", "https://example.com/saved/a"),
+		"saved/3":           []byte("not a message of MH folder"),
+		"archive/Feeds.mbx": append([]byte("From - Wed May 11 14:31:59 2016\r\n"), testFeedMessage("Mbox", "https://example.com/mbox/a")...),
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !IsMHFolder(dir) {
+		t.Fatalf("%s is not MH folder", dir)
+	}
+
+	feed := &model.Feed{FeedURL: "https://example.com/feed.xml"}
+	config := &EntryConfig{FeedHelper: newTestFeedHelper(t, "", feed), User: &model.User{ID: 1}, DefaultFeed: feed, Quiet: true}
+
+	entries, err := GetEntriesForMH(config, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var urls []string
+	for _, entry := range entries {
+		urls = append(urls, entry.URL)
+	}
+	sort.Strings(urls)
+
+	want := "https://example.com/mbox/a https://example.com/mh/1 https://example.com/mh/2 https://example.com/saved/a"
+	if got := strings.Join(urls, " "); got != want {
+		t.Errorf("got entries %s, want %s", got, want)
+	}
+}
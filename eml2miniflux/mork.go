@@ -0,0 +1,396 @@
+package eml2miniflux
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Mork is the database format of Thunderbird summary files (.msf).
+// Only the subset required to read the rows of tables is implemented:
+// dictionaries of columns and atoms, tables, rows and their cells.
+// Transaction groups are read when they are committed.
+
+// morkRow holds cells of a row: column name => value
+type morkRow map[string]string
+
+type morkDatabase struct {
+	// Rows by scope name and row ID
+	Rows map[string]map[string]morkRow
+
+	columns map[string]string
+	atoms   map[string]string
+}
+
+type morkParser struct {
+	data []byte
+	pos  int
+	db   *morkDatabase
+}
+
+func parseMork(data []byte) (*morkDatabase, error) {
+	p := morkParser{
+		data: data,
+		db: &morkDatabase{
+			Rows:    make(map[string]map[string]morkRow),
+			columns: make(map[string]string),
+			atoms:   make(map[string]string),
+		},
+	}
+
+	err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	return p.db, nil
+}
+
+func (p *morkParser) eof() bool {
+	return p.pos >= len(p.data)
+}
+
+func (p *morkParser) peek() byte {
+	return p.data[p.pos]
+}
+
+func (p *morkParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("mork: offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+// Skip whitespace and `//` comments
+func (p *morkParser) skipSpace() {
+	for !p.eof() {
+		c := p.peek()
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+			p.pos++
+		} else if c == '/' && p.pos+1 < len(p.data) && p.data[p.pos+1] == '/' {
+			end := bytes.IndexByte(p.data[p.pos:], '\n')
+			if end < 0 {
+				p.pos = len(p.data)
+			} else {
+				p.pos += end + 1
+			}
+		} else {
+			return
+		}
+	}
+}
+
+// Read a token up to any of the delimiters or whitespace
+func (p *morkParser) token(delimiters string) string {
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' || strings.IndexByte(delimiters, c) >= 0 {
+			break
+		}
+		p.pos++
+	}
+	return string(p.data[start:p.pos])
+}
+
+// Read a literal value up to the closing parenthesis, which is consumed
+func (p *morkParser) value() (string, error) {
+	var buf bytes.Buffer
+
+	for !p.eof() {
+		c := p.peek()
+		p.pos++
+
+		switch c {
+		case ')':
+			return buf.String(), nil
+		case '\\':
+			if p.eof() {
+				break
+			}
+			next := p.peek()
+			p.pos++
+			if next == '\r' {
+				// line continuation
+				if !p.eof() && p.peek() == '\n' {
+					p.pos++
+				}
+			} else if next != '\n' {
+				buf.WriteByte(next)
+			}
+		case '$':
+			if p.pos+2 <= len(p.data) {
+				b, err := strconv.ParseUint(string(p.data[p.pos:p.pos+2]), 16, 8)
+				if err == nil {
+					buf.WriteByte(byte(b))
+					p.pos += 2
+					continue
+				}
+			}
+			buf.WriteByte(c)
+		case '\r', '\n':
+			// values may be wrapped
+		default:
+			buf.WriteByte(c)
+		}
+	}
+
+	return "", p.errorf("unterminated value")
+}
+
+// Skip the block up to the matching closing character
+func (p *morkParser) skipBlock(open byte, close byte) {
+	depth := 0
+	for !p.eof() {
+		c := p.peek()
+		p.pos++
+		if c == open {
+			depth++
+		} else if c == close {
+			depth--
+			if depth == 0 {
+				return
+			}
+		}
+	}
+}
+
+// Resolve column reference: `^80` refers to the column dictionary, otherwise it is the column name
+func (p *morkParser) column(ref string) string {
+	if strings.HasPrefix(ref, "^") {
+		if name, ok := p.db.columns[strings.ToUpper(ref[1:])]; ok {
+			return name
+		}
+	}
+	return ref
+}
+
+// Resolve ID with optional scope, ex.: `1A:^80`; the default scope is used when it is omitted
+func (p *morkParser) scopedID(id string, defaultScope string) (string, string) {
+	id, scope, found := strings.Cut(id, ":")
+	if !found {
+		return strings.ToUpper(id), defaultScope
+	}
+	return strings.ToUpper(id), p.column(scope)
+}
+
+func (p *morkParser) parse() error {
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return nil
+		}
+
+		var err error
+		switch p.peek() {
+		case '<':
+			err = p.parseDict()
+		case '{':
+			err = p.parseTable()
+		case '[':
+			err = p.parseRow("")
+		case '@':
+			p.parseGroupMark()
+		default:
+			p.pos++
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Group marks, ex.: `@$${1{@` and `@$$}1}@`. Changes of a group are applied when the group is committed;
+// the group aborted with `@$$}~abort~1}@`, or not finished at the end of the file, is skipped.
+func (p *morkParser) parseGroupMark() {
+	if !bytes.HasPrefix(p.data[p.pos:], []byte("@$$")) {
+		p.pos++
+		return
+	}
+
+	isStart := bytes.HasPrefix(p.data[p.pos+3:], []byte("{"))
+
+	end := bytes.IndexByte(p.data[p.pos+3:], '@')
+	if end < 0 {
+		p.pos = len(p.data)
+		return
+	}
+	p.pos += 3 + end + 1
+
+	if !isStart {
+		return
+	}
+
+	groupEnd := bytes.Index(p.data[p.pos:], []byte("@$$}"))
+	if groupEnd < 0 {
+		p.pos = len(p.data)
+		return
+	}
+
+	if bytes.HasPrefix(p.data[p.pos+groupEnd+4:], []byte("~abort~")) {
+		p.pos += groupEnd
+		p.parseGroupMark()
+	}
+}
+
+// Dictionary, ex.: `<(a=c)> (80=ns:msg:db:row:scope:msgs:all)(81=subject)>`
+func (p *morkParser) parseDict() error {
+	p.pos++ // '<'
+	isColumns := false
+
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return p.errorf("unterminated dictionary")
+		}
+
+		switch p.peek() {
+		case '>':
+			p.pos++
+			return nil
+		case '<':
+			start := p.pos
+			p.skipBlock('<', '>')
+			meta := strings.ReplaceAll(string(p.data[start:p.pos]), " ", "")
+			isColumns = strings.Contains(meta, "(a=c)")
+		case '(':
+			p.pos++
+			id := strings.ToUpper(p.token("=)"))
+			if p.eof() || p.peek() != '=' {
+				return p.errorf("wrong dictionary cell: %s", id)
+			}
+			p.pos++
+			value, err := p.value()
+			if err != nil {
+				return err
+			}
+			if isColumns {
+				p.db.columns[id] = value
+			} else {
+				p.db.atoms[id] = value
+			}
+		default:
+			p.pos++
+		}
+	}
+}
+
+// Table, ex.: `{1:^80 {(k^88:c)(s=9)} [1(^83=12)(^84^90)] }`
+func (p *morkParser) parseTable() error {
+	p.pos++ // '{'
+	p.skipSpace()
+	_, scope := p.scopedID(p.token("{[}"), "")
+
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return p.errorf("unterminated table")
+		}
+
+		switch p.peek() {
+		case '}':
+			p.pos++
+			return nil
+		case '{':
+			// table meta
+			p.skipBlock('{', '}')
+		case '[':
+			err := p.parseRow(scope)
+			if err != nil {
+				return err
+			}
+		default:
+			// references to existent rows
+			p.pos++
+		}
+	}
+}
+
+// Row, ex.: `[1A(^83=12)(^84^90)]`; `[-1A ...]` replaces all cells of the row
+func (p *morkParser) parseRow(tableScope string) error {
+	p.pos++ // '['
+	p.skipSpace()
+
+	cut := false
+	if !p.eof() && p.peek() == '-' {
+		cut = true
+		p.pos++
+	}
+
+	id, scope := p.scopedID(p.token("(]["), tableScope)
+
+	rows, ok := p.db.Rows[scope]
+	if !ok {
+		rows = make(map[string]morkRow)
+		p.db.Rows[scope] = rows
+	}
+
+	row, ok := rows[id]
+	if !ok || cut {
+		row = make(morkRow)
+		rows[id] = row
+	}
+
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return p.errorf("unterminated row")
+		}
+
+		switch p.peek() {
+		case ']':
+			p.pos++
+			return nil
+		case '[':
+			// row meta
+			p.skipBlock('[', ']')
+		case '(':
+			p.pos++
+			err := p.parseCell(row)
+			if err != nil {
+				return err
+			}
+		default:
+			p.pos++
+		}
+	}
+}
+
+// Cell, ex.: `(^83=12)` with literal value, or `(^84^90)` with atom reference
+func (p *morkParser) parseCell(row morkRow) error {
+	ref := ""
+	if !p.eof() && p.peek() == '^' {
+		p.pos++
+		ref = "^" + p.token("=^)")
+	} else {
+		ref = p.token("=^)")
+	}
+	column := p.column(ref)
+
+	if p.eof() {
+		return p.errorf("unterminated cell")
+	}
+
+	switch p.peek() {
+	case '=':
+		p.pos++
+		value, err := p.value()
+		if err != nil {
+			return err
+		}
+		row[column] = value
+	case '^':
+		p.pos++
+		atom := strings.ToUpper(p.token(")"))
+		if !p.eof() && p.peek() == ')' {
+			p.pos++
+		}
+		row[column] = p.db.atoms[atom]
+	case ')':
+		p.pos++
+		row[column] = ""
+	default:
+		return p.errorf("wrong cell of column: %s", column)
+	}
+
+	return nil
+}
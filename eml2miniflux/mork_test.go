@@ -0,0 +1,155 @@
+package eml2miniflux
+
+import (
+	"testing"
+)
+
+const morkTestHeader = `// <!-- <mdb:mork:z v="1.4"/> -->
+< <(a=c)> // (f=iso-8859-1)
+  (B8=ns:msg:db:row:scope:msgs:all)(80=subject)(81=flags)(82=message-id)>
+<(90=Hello)(91=1)(A0=World)>
+`
+
+func TestParseMorkValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		mork  string
+		value string
+	}{
+		{name: "literal", mork: `[1:^B8(^80=Plain subject)]`, value: "Plain subject"},
+		{name: "atom reference", mork: `[1:^B8(^80^90)]`, value: "Hello"},
+		{name: "lowercase atom reference", mork: `[1:^B8(^80^a0)]`, value: "World"},
+		{name: "escaped parenthesis", mork: `[1:^B8(^80=a \) b)]`, value: "a ) b"},
+		{name: "escaped backslash and dollar", mork: `[1:^B8(^80=a\\b\$c)]`, value: `a\b$c`},
+		{name: "hex escape", mork: `[1:^B8(^80=caf$C3$A9)]`, value: "caf\xc3\xa9"},
+		{name: "dollar without hex", mork: `[1:^B8(^80=$x)]`, value: "$x"},
+		{name: "line continuation", mork: "[1:^B8(^80=long \\\nsubject)]", value: "long subject"},
+		{name: "empty cell", mork: `[1:^B8(^80)]`, value: ""},
+		{name: "column by name", mork: `[1:^B8(subject=By name)]`, value: "By name"},
+	}
+
+	for _, test := range tests {
+		db, err := parseMork([]byte(morkTestHeader + test.mork))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+
+		row, ok := db.Rows["ns:msg:db:row:scope:msgs:all"]["1"]
+		if !ok {
+			t.Errorf("%s: row is not found", test.name)
+			continue
+		}
+		if row["subject"] != test.value {
+			t.Errorf("%s: got %q, want %q", test.name, row["subject"], test.value)
+		}
+	}
+}
+
+func TestParseMorkTables(t *testing.T) {
+	scope := "ns:msg:db:row:scope:msgs:all"
+
+	tests := []struct {
+		name string
+		mork string
+		rows map[string]morkRow
+	}{
+		{
+			name: "table rows take the scope of the table",
+			mork: `{1:^B8 {(k^C0:c)(s=9)} [1(^80=One)(^81=1)] [2(^80=Two)] }`,
+			rows: map[string]morkRow{
+				"1": {"subject": "One", "flags": "1"},
+				"2": {"subject": "Two"},
+			},
+		},
+		{
+			name: "row update adds and replaces cells",
+			mork: `{1:^B8 [1(^80=One)(^81=1)] }
+@$${2{@[1:^B8(^81=11)(^82=id)]@$$}2}@`,
+			rows: map[string]morkRow{
+				"1": {"subject": "One", "flags": "11", "message-id": "id"},
+			},
+		},
+		{
+			name: "cut row replaces all cells",
+			mork: `{1:^B8 [1(^80=One)(^81=1)] }
+@$${3{@[-1:^B8(^81=0)]@$$}3}@`,
+			rows: map[string]morkRow{
+				"1": {"flags": "0"},
+			},
+		},
+		{
+			name: "groups and references to existent rows",
+			mork: `{1:^B8 [1(^80=One)] }
+@$${4{@{1:^B8 1 [2(^80=Two)]}@$$}4}@
+@$${5{@<(92=Three)>[3:^B8(^80^92)]@$$}5}@`,
+			rows: map[string]morkRow{
+				"1": {"subject": "One"},
+				"2": {"subject": "Two"},
+				"3": {"subject": "Three"},
+			},
+		},
+		{
+			name: "aborted and unfinished groups are skipped",
+			mork: `{1:^B8 [1(^80=One)(^81=1)] }
+@$${6{@[1:^B8(^81=0)][2:^B8(^80=Two)]@$$}~abort~6}@
+@$${7{@[3:^B8(^80=Three)]@$$}7}@
+@$${8{@[1:^B8(^80=Eight)]`,
+			rows: map[string]morkRow{
+				"1": {"subject": "One", "flags": "1"},
+				"3": {"subject": "Three"},
+			},
+		},
+		{
+			name: "hex row IDs are case insensitive",
+			mork: `[1a:^B8(^80=Lower)] [1A:^B8(^81=1)]`,
+			rows: map[string]morkRow{
+				"1A": {"subject": "Lower", "flags": "1"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		db, err := parseMork([]byte(morkTestHeader + test.mork))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+
+		rows := db.Rows[scope]
+		if len(rows) != len(test.rows) {
+			t.Errorf("%s: got %d rows, want %d: %v", test.name, len(rows), len(test.rows), rows)
+			continue
+		}
+		for id, want := range test.rows {
+			got := rows[id]
+			if len(got) != len(want) {
+				t.Errorf("%s: row %s: got %v, want %v", test.name, id, got, want)
+				continue
+			}
+			for column, value := range want {
+				if got[column] != value {
+					t.Errorf("%s: row %s: column %s: got %q, want %q", test.name, id, column, got[column], value)
+				}
+			}
+		}
+	}
+}
+
+func TestParseMorkErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		mork string
+	}{
+		{name: "unterminated dictionary", mork: `<(80=subject)`},
+		{name: "unterminated value", mork: `[1(^80=subject`},
+		{name: "unterminated row", mork: `[1(^80=subject)`},
+		{name: "unterminated table", mork: `{1:^80 [1(^80=subject)]`},
+	}
+
+	for _, test := range tests {
+		if _, err := parseMork([]byte(test.mork)); err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package eml2miniflux
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/sg3des/eml"
+)
+
+const (
+	// Flags of X-Mozilla-Status header
+	mozillaStatusRead     = 0x0001
+	mozillaStatusMarked   = 0x0004
+	mozillaStatusExpunged = 0x0008
+
+	// Label (tag of Thunderbird prior to version 2) within X-Mozilla-Status2 header
+	mozillaStatus2Labels     = 0x0E000000
+	mozillaStatus2LabelShift = 25
+)
+
+var (
+	// Names of the default tags of Thunderbird
+	mozillaDefaultTags = map[string]string{
+		"$label1": "Important",
+		"$label2": "Work",
+		"$label3": "Personal",
+		"$label4": "To Do",
+		"$label5": "Later",
+	}
+
+	// Keywords set by Thunderbird itself rather than by the user
+	mozillaSystemKeywords = map[string]bool{
+		"junk":    true,
+		"nonjunk": true,
+		"notjunk": true,
+	}
+)
+
+// State of the message according to X-Mozilla-* headers
+type mozillaState struct {
+	HasStatus bool
+	Read      bool
+	Marked    bool
+	Expunged  bool
+	Tags      []string
+}
+
+func parseHexFlags(value string) (uint32, bool) {
+	if len(value) == 0 {
+		return 0, false
+	}
+
+	flags, err := strconv.ParseUint(value, 16, 32)
+	if err != nil {
+		return 0, false
+	}
+
+	return uint32(flags), true
+}
+
+// Convert Thunderbird keyword to a tag name; empty string is returned for system keywords
+func mozillaKeywordTag(keyword string) string {
+	if name, ok := mozillaDefaultTags[keyword]; ok {
+		return name
+	}
+
+	if strings.HasPrefix(keyword, "$") || mozillaSystemKeywords[strings.ToLower(keyword)] {
+		return ""
+	}
+
+	return keyword
+}
+
+// Decode X-Mozilla-Status, X-Mozilla-Status2 and X-Mozilla-Keys headers
+func mozillaMessageState(message *eml.Message) mozillaState {
+	var state mozillaState
+
+	if flags, ok := parseHexFlags(messageHeader(message, "X-Mozilla-Status")); ok {
+		state.HasStatus = true
+		state.Read = flags&mozillaStatusRead != 0
+		state.Marked = flags&mozillaStatusMarked != 0
+		state.Expunged = flags&mozillaStatusExpunged != 0
+	}
+
+	if flags, ok := parseHexFlags(messageHeader(message, "X-Mozilla-Status2")); ok {
+		label := (flags & mozillaStatus2Labels) >> mozillaStatus2LabelShift
+		if label > 0 {
+			state.Tags = append(state.Tags, mozillaKeywordTag("$label"+strconv.Itoa(int(label))))
+		}
+	}
+
+	for _, keyword := range strings.Fields(messageHeader(message, "X-Mozilla-Keys")) {
+		if tag := mozillaKeywordTag(keyword); len(tag) > 0 {
+			state.Tags = append(state.Tags, tag)
+		}
+	}
+
+	return state
+}
+
+// Thunderbird keeps deleted messages within mbox until the folder is compacted
+func isExpungedMessage(message *eml.Message) bool {
+	return mozillaMessageState(message).Expunged
+}
+
+// Merge tag lists, skipping empty and duplicate tags
+func mergeTags(lists ...[]string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+
+	for _, list := range lists {
+		for _, tag := range list {
+			if len(tag) > 0 && !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	return tags
+}
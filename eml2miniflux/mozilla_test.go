@@ -0,0 +1,141 @@
+package eml2miniflux
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"miniflux.app/model"
+)
+
+func TestMozillaMessageState(t *testing.T) {
+	tests := []struct {
+		name      string
+		headers   []string
+		hasStatus bool
+		read      bool
+		marked    bool
+		expunged  bool
+		tags      string
+	}{
+		{name: "no headers"},
+		{name: "unread", headers: []string{"X-Mozilla-Status: 0000"}, hasStatus: true},
+		{name: "read", headers: []string{"X-Mozilla-Status: 0001"}, hasStatus: true, read: true},
+		{name: "read and marked", headers: []string{"X-Mozilla-Status: 0005"}, hasStatus: true, read: true, marked: true},
+		{name: "replied, marked", headers: []string{"X-Mozilla-Status: 0006"}, hasStatus: true, marked: true},
+		{name: "expunged", headers: []string{"X-Mozilla-Status: 0009"}, hasStatus: true, read: true, expunged: true},
+		{name: "invalid status", headers: []string{"X-Mozilla-Status: read"}},
+		{name: "label of Status2", headers: []string{"X-Mozilla-Status2: 04000000"}, tags: "Work"},
+		{name: "label of Status2 with other flags", headers: []string{"X-Mozilla-Status2: 0a010000"}, tags: "Later"},
+		{
+			name:    "default and user keywords",
+			headers: []string{"X-Mozilla-Keys: $label1 news    $label4"},
+			tags:    "Important news To Do",
+		},
+		{
+			name:    "system keywords are skipped",
+			headers: []string{"X-Mozilla-Keys: junk NonJunk $Forwarded $label9 Go"},
+			tags:    "Go",
+		},
+		{
+			name:      "all headers",
+			headers:   []string{"X-Mozilla-Status: 0001", "X-Mozilla-Status2: 02000000", "X-Mozilla-Keys: $label2 go"},
+			hasStatus: true,
+			read:      true,
+			tags:      "Important Work go",
+		},
+	}
+
+	for _, test := range tests {
+		state := mozillaMessageState(testHtmlMessage(t, test.headers, "<p>Text</p>"))
+		if state.HasStatus != test.hasStatus || state.Read != test.read || state.Marked != test.marked || state.Expunged != test.expunged {
+			t.Errorf("%s: got status %v, read %v, marked %v, expunged %v", test.name, state.HasStatus, state.Read, state.Marked, state.Expunged)
+		}
+		if tags := strings.Join(state.Tags, " "); tags != test.tags {
+			t.Errorf("%s: got tags %q, want %q", test.name, tags, test.tags)
+		}
+	}
+}
+
+// State known from the storage (.msf summary, Maildir or IMAP flags) takes precedence over the headers,
+// and the headers take precedence over the default status
+func TestCreateEntryForEMLStatePrecedence(t *testing.T) {
+	tests := []struct {
+		name          string
+		headers       []string
+		info          *MessageInfo
+		defaultStatus string
+		status        string
+		starred       bool
+	}{
+		{name: "no state", status: model.EntryStatusUnread},
+		{name: "default status", defaultStatus: model.EntryStatusRead, status: model.EntryStatusRead},
+		{
+			name:          "headers over default status",
+			headers:       []string{"X-Mozilla-Status: 0004"},
+			defaultStatus: model.EntryStatusRead,
+			status:        model.EntryStatusUnread,
+			starred:       true,
+		},
+		{
+			name:    "storage over headers",
+			headers: []string{"X-Mozilla-Status: 0005"},
+			info:    &MessageInfo{HasFlags: true},
+			status:  model.EntryStatusUnread,
+		},
+		{
+			name:          "storage over default status",
+			info:          &MessageInfo{HasFlags: true, Seen: true, Flagged: true},
+			defaultStatus: model.EntryStatusUnread,
+			status:        model.EntryStatusRead,
+			starred:       true,
+		},
+		{
+			name:    "folder without flags",
+			info:    &MessageInfo{Folder: "Feeds"},
+			status:  model.EntryStatusRead,
+			headers: []string{"X-Mozilla-Status: 0001"},
+		},
+	}
+
+	feed := &model.Feed{FeedURL: "https://example.com/feed.xml"}
+	feedHelper := newTestFeedHelper(t, "", feed)
+
+	for _, test := range tests {
+		config := &EntryConfig{FeedHelper: feedHelper, User: &model.User{ID: 1}, DefaultFeed: feed, DefaultStatus: test.defaultStatus}
+		headers := append([]string{"X-Mozilla-Keys: $label1"}, test.headers...)
+
+		entry, err := CreateEntryForEML(testHtmlMessage(t, headers, "<p>Text</p>"), test.info, config)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		if entry.Status != test.status || entry.Starred != test.starred {
+			t.Errorf("%s: got status %s, starred %v, want %s, %v", test.name, entry.Status, entry.Starred, test.status, test.starred)
+		}
+		if strings.Join(entry.Tags, " ") != "Important" {
+			t.Errorf("%s: got tags %v", test.name, entry.Tags)
+		}
+	}
+}
+
+func TestFolderSummaryPrecedence(t *testing.T) {
+	feed := &model.Feed{FeedURL: "https://example.com/feed.xml"}
+	c := newEntryCollector(&EntryConfig{FeedHelper: newTestFeedHelper(t, "", feed), User: &model.User{ID: 1}, DefaultFeed: feed, DefaultStatus: model.EntryStatusUnread})
+
+	// the summary of the folder has the message read and not marked, unlike its headers
+	folder := filepath.Join(t.TempDir(), "Feeds")
+	c.summaries = map[string]msfSummary{folderKey(folder): {"post@example.com": mozillaStatusRead}}
+
+	headers := []string{"Message-ID: <post@example.com>", "X-Mozilla-Status: 0004"}
+	entries, err := c.createEntries("post.eml", testHtmlMessage(t, headers, "<p>Text</p>"), &MessageInfo{Folder: folder})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries", len(entries))
+	}
+	if entries[0].Status != model.EntryStatusRead || entries[0].Starred {
+		t.Errorf("got status %s, starred %v", entries[0].Status, entries[0].Starred)
+	}
+}
@@ -0,0 +1,97 @@
+package eml2miniflux
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+
+	"github.com/sg3des/eml"
+)
+
+const (
+	msfSuffix = ".msf"
+
+	// Scope of message rows in Thunderbird summary file
+	msfMessagesScope = "ns:msg:db:row:scope:msgs:all"
+)
+
+// Message flags from Thunderbird summary file: Message-ID => flags,
+// the flags have the same meaning as of X-Mozilla-Status header
+type msfSummary map[string]uint32
+
+// Load summary file of Thunderbird folder; nil is returned when the file does not exist
+func loadMsfSummary(folderPath string) (msfSummary, error) {
+	data, err := os.ReadFile(folderPath + msfSuffix)
+	if os.IsNotExist(err) || errors.Is(err, syscall.ENOTDIR) {
+		// folders of archive members have no summary on disk
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	db, err := parseMork(data)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := make(msfSummary)
+	for _, row := range db.Rows[msfMessagesScope] {
+		messageId, ok := row["message-id"]
+		if !ok || len(messageId) == 0 {
+			continue
+		}
+
+		flags, err := strconv.ParseUint(row["flags"], 16, 32)
+		if err != nil {
+			continue
+		}
+
+		summary[messageId] = uint32(flags)
+	}
+
+	return summary, nil
+}
+
+// Get summary of the message folder, loading it on first access
+func (c *entryCollector) folderSummary(folderPath string) msfSummary {
+	key := folderKey(folderPath)
+
+	if c.summaries == nil {
+		c.summaries = make(map[string]msfSummary)
+	}
+
+	summary, ok := c.summaries[key]
+	if !ok {
+		var err error
+		summary, err = loadMsfSummary(folderPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot read folder summary: %s%s: %s\n", folderPath, msfSuffix, err)
+		}
+		c.summaries[key] = summary
+	}
+
+	return summary
+}
+
+// Apply read and flagged state kept by Thunderbird in the summary file of the message folder
+func (c *entryCollector) applyFolderSummary(message *eml.Message, info *MessageInfo) {
+	if info == nil || len(info.Folder) == 0 || len(message.MessageId) == 0 {
+		return
+	}
+
+	summary := c.folderSummary(info.Folder)
+	if summary == nil {
+		return
+	}
+
+	flags, ok := summary[message.MessageId]
+	if !ok {
+		return
+	}
+
+	info.HasFlags = true
+	info.Seen = flags&mozillaStatusRead != 0
+	info.Flagged = flags&mozillaStatusMarked != 0
+}
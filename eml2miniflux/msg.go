@@ -0,0 +1,371 @@
+package eml2miniflux
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/richardlehane/mscfb"
+	"github.com/sg3des/eml"
+	"golang.org/x/net/html/charset"
+	"miniflux.app/model"
+)
+
+const (
+	msgPropertyPrefix   = "__substg1.0_"
+	msgPropertiesStream = "__properties_version1.0"
+	msgNameIdStorage    = "__nameid_version1.0"
+
+	// Header of the fixed length properties stream of the top-level message
+	msgPropertiesHeaderSize = 32
+	msgPropertyEntrySize    = 16
+	msgNameIdEntrySize      = 8
+
+	// Property types
+	msgTypeLong    = 0x0003
+	msgTypeString8 = 0x001E
+	msgTypeUnicode = 0x001F
+	msgTypeSysTime = 0x0040
+	msgTypeBinary  = 0x0102
+
+	// Property IDs
+	msgPropSubject             = 0x0037
+	msgPropClientSubmitTime    = 0x0039
+	msgPropTransportHeaders    = 0x007D
+	msgPropSenderName          = 0x0C1A
+	msgPropSenderEmail         = 0x0C1F
+	msgPropMessageDeliveryTime = 0x0E06
+	msgPropMessageFlags        = 0x0E07
+	msgPropBody                = 0x1000
+	msgPropHtml                = 0x1013
+	msgPropInternetMessageId   = 0x1035
+	msgPropFlagStatus          = 0x1090
+	msgPropSenderSmtpAddress   = 0x5D01
+
+	msgFlagRead          = 0x0001
+	msgFlagStatusFlagged = 2
+
+	// Named properties of Outlook RSS items (PSETID_PostRss)
+	msgLidPostRssChannelLink = 0x8900
+	msgLidPostRssItemLink    = 0x8901
+	msgLidPostRssItemGuid    = 0x8903
+	msgLidPostRssChannel     = 0x8904
+
+	// Seconds between Windows FILETIME epoch (1601) and Unix epoch
+	msgFileTimeUnixOffset = 11644473600
+
+	// Named property IDs start from this value
+	msgNamedPropertyBase = 0x8000
+	// GUID index of the first GUID of the name ID GUID stream
+	msgNameIdFirstGuid = 3
+)
+
+var (
+	msgSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+	// {00062041-0000-0000-C000-000000000046} as stored in the file
+	msgPostRssGuid = []byte{0x41, 0x20, 0x06, 0x00, 0x00, 0x00, 0x00, 0x00, 0xC0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x46}
+)
+
+// Properties of Outlook message read from its streams
+type msgProperties struct {
+	// Variable length properties by ID; the value is decoded to string when possible
+	strings  map[uint16]string
+	binaries map[uint16][]byte
+	// Fixed length properties by ID
+	fixed map[uint16]uint64
+	// Named properties of Outlook RSS item by LID
+	rss map[uint32]string
+}
+
+// Sender of Outlook message; Outlook RSS items may have the name only
+type msgAddress struct {
+	name  string
+	email string
+}
+
+func (a msgAddress) Name() string {
+	if len(a.name) == 0 {
+		return a.email
+	}
+	return a.name
+}
+
+func (a msgAddress) Email() string {
+	return a.email
+}
+
+func (a msgAddress) String() string {
+	if len(a.email) == 0 {
+		return a.name
+	} else if len(a.name) == 0 {
+		return a.email
+	}
+	return fmt.Sprintf("%s <%s>", a.name, a.email)
+}
+
+// IsMsgFile determines if a file represented by `filePath`
+// is Outlook message, i.e. OLE compound file with extension '.msg'
+func IsMsgFile(filePath string) bool {
+	if !strings.HasSuffix(strings.ToLower(filePath), ".msg") {
+		return false
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	header := make([]byte, len(msgSignature))
+	_, err = io.ReadFull(file, header)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(header, msgSignature)
+}
+
+func decodeMsgUnicode(data []byte) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(data[i*2:])
+	}
+	return strings.TrimRight(string(utf16.Decode(units)), "\x00")
+}
+
+func msgFileTime(value uint64) time.Time {
+	if value == 0 {
+		return time.Time{}
+	}
+	// FILETIME counts 100-nanosecond intervals
+	return time.Unix(int64(value/10000000)-msgFileTimeUnixOffset, int64(value%10000000)*100)
+}
+
+// Read properties of the top-level message, skipping recipients and attachments
+func readMsgProperties(ra io.ReaderAt) (*msgProperties, error) {
+	reader, err := mscfb.New(ra)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open compound file: %s", err)
+	}
+
+	props := &msgProperties{
+		strings:  make(map[uint16]string),
+		binaries: make(map[uint16][]byte),
+		fixed:    make(map[uint16]uint64),
+		rss:      make(map[uint32]string),
+	}
+	nameId := make(map[string][]byte)
+
+	for file, err := reader.Next(); err != io.EOF; file, err = reader.Next() {
+		if err != nil {
+			return nil, fmt.Errorf("cannot read compound file: %s", err)
+		}
+
+		isTopLevel := len(file.Path) == 0
+		isNameId := len(file.Path) == 1 && file.Path[0] == msgNameIdStorage
+		isProperty := file.Name == msgPropertiesStream || strings.HasPrefix(file.Name, msgPropertyPrefix)
+		if !isProperty || (!isTopLevel && !isNameId) {
+			continue
+		}
+
+		data := make([]byte, file.Size)
+		if _, err = io.ReadFull(file, data); err != nil {
+			return nil, fmt.Errorf("cannot read stream %s: %s", file.Name, err)
+		}
+
+		if isNameId {
+			nameId[file.Name] = data
+		} else if file.Name == msgPropertiesStream {
+			props.readFixed(data)
+		} else if strings.HasPrefix(file.Name, msgPropertyPrefix) {
+			tag, err := strconv.ParseUint(strings.TrimPrefix(file.Name, msgPropertyPrefix), 16, 32)
+			if err != nil {
+				continue
+			}
+			props.readVariable(uint16(tag>>16), uint16(tag), data)
+		}
+	}
+
+	props.mapNamedProperties(nameId)
+
+	return props, nil
+}
+
+func (props *msgProperties) readFixed(data []byte) {
+	for offset := msgPropertiesHeaderSize; offset+msgPropertyEntrySize <= len(data); offset += msgPropertyEntrySize {
+		tag := binary.LittleEndian.Uint32(data[offset:])
+		switch uint16(tag) {
+		case msgTypeLong, msgTypeSysTime:
+			props.fixed[uint16(tag>>16)] = binary.LittleEndian.Uint64(data[offset+8:])
+		}
+	}
+}
+
+func (props *msgProperties) readVariable(id uint16, propType uint16, data []byte) {
+	switch propType {
+	case msgTypeUnicode:
+		props.strings[id] = decodeMsgUnicode(data)
+	case msgTypeString8:
+		props.strings[id] = strings.TrimRight(string(data), "\x00")
+	case msgTypeBinary:
+		props.binaries[id] = data
+	}
+}
+
+// Find named properties of Outlook RSS item by their IDs in the file, which are assigned per file
+func (props *msgProperties) mapNamedProperties(nameId map[string][]byte) {
+	guids := nameId[fmt.Sprintf("%s%04X%04X", msgPropertyPrefix, 0x0002, msgTypeBinary)]
+	entries := nameId[fmt.Sprintf("%s%04X%04X", msgPropertyPrefix, 0x0003, msgTypeBinary)]
+
+	for offset := 0; offset+msgNameIdEntrySize <= len(entries); offset += msgNameIdEntrySize {
+		lid := binary.LittleEndian.Uint32(entries[offset:])
+		indexAndKind := binary.LittleEndian.Uint16(entries[offset+4:])
+		propertyIndex := binary.LittleEndian.Uint16(entries[offset+6:])
+
+		// String named properties are not used
+		if indexAndKind&1 != 0 {
+			continue
+		}
+
+		guidIndex := int(indexAndKind >> 1)
+		guidOffset := (guidIndex - msgNameIdFirstGuid) * len(msgPostRssGuid)
+		if guidIndex < msgNameIdFirstGuid || guidOffset+len(msgPostRssGuid) > len(guids) ||
+			!bytes.Equal(guids[guidOffset:guidOffset+len(msgPostRssGuid)], msgPostRssGuid) {
+			continue
+		}
+
+		id := uint16(msgNamedPropertyBase + int(propertyIndex))
+		if value, ok := props.strings[id]; ok {
+			props.rss[lid] = value
+		}
+	}
+}
+
+// HTML body is stored in the code page of the message, which is detected like in a web page
+func (props *msgProperties) html() string {
+	data, ok := props.binaries[msgPropHtml]
+	if !ok {
+		return props.strings[msgPropHtml]
+	}
+
+	if !utf8.Valid(data) {
+		if r, err := charset.NewReader(bytes.NewReader(data), "text/html"); err == nil {
+			if decoded, err := io.ReadAll(r); err == nil {
+				data = decoded
+			}
+		}
+	}
+
+	return strings.TrimRight(string(data), "\x00")
+}
+
+// Build the message from Outlook properties, so it is processed as EML.
+// Message info carries the read state and the feed of Outlook RSS item.
+func (props *msgProperties) message() (*eml.Message, *MessageInfo) {
+	message := &eml.Message{}
+	info := &MessageInfo{}
+
+	// Internet headers of received mail provide the fields absent in the properties
+	if headers, ok := props.strings[msgPropTransportHeaders]; ok {
+		if parsed, err := eml.Parse([]byte(strings.TrimSpace(headers) + "\r\n\r\n")); err == nil {
+			message.HeaderInfo = parsed.HeaderInfo
+		}
+	}
+
+	if subject, ok := props.strings[msgPropSubject]; ok {
+		message.Subject = subject
+	}
+	if messageId, ok := props.strings[msgPropInternetMessageId]; ok {
+		message.MessageId = strings.Trim(messageId, "<>")
+	} else if guid, ok := props.rss[msgLidPostRssItemGuid]; ok {
+		message.MessageId = guid
+	}
+	if link, ok := props.rss[msgLidPostRssItemLink]; ok {
+		message.ContentBase = link
+	}
+
+	if date := msgFileTime(props.fixed[msgPropClientSubmitTime]); !date.IsZero() {
+		message.Date = date
+	}
+	if date := msgFileTime(props.fixed[msgPropMessageDeliveryTime]); !date.IsZero() {
+		message.ReceivedDate = date
+	}
+	if message.Date.IsZero() {
+		message.Date = message.ReceivedDate
+	}
+
+	sender := msgAddress{name: props.strings[msgPropSenderName], email: props.strings[msgPropSenderSmtpAddress]}
+	if len(sender.email) == 0 && strings.Contains(props.strings[msgPropSenderEmail], "@") {
+		sender.email = props.strings[msgPropSenderEmail]
+	}
+	if len(sender.name) > 0 || len(sender.email) > 0 {
+		message.Sender = sender
+	}
+
+	message.Html = props.html()
+	message.Text = props.strings[msgPropBody]
+
+	if flags, ok := props.fixed[msgPropMessageFlags]; ok {
+		info.HasFlags = true
+		info.Seen = flags&msgFlagRead != 0
+		info.Flagged = props.fixed[msgPropFlagStatus] == msgFlagStatusFlagged
+	}
+	info.FeedUrl = props.rss[msgLidPostRssChannelLink]
+	info.FeedTitle = props.rss[msgLidPostRssChannel]
+
+	return message, info
+}
+
+// Parse Outlook message; `folder` is the folder containing the message
+func parseMsg(ra io.ReaderAt, folder string) (*eml.Message, *MessageInfo, error) {
+	props, err := readMsgProperties(ra)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot parse MSG: %s", err)
+	}
+
+	message, info := props.message()
+	info.Folder = folder
+
+	return message, info, nil
+}
+
+func loadMsg(filePath string) (*eml.Message, *MessageInfo, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot open file: %s", err)
+	}
+	defer file.Close()
+
+	return parseMsg(file, filepath.Dir(filePath))
+}
+
+// Create entries for the Outlook message file; only the failure to read the file is returned
+func (c *entryCollector) addMsgFile(path string) error {
+	c.countMessage()
+
+	message, info, err := loadMsg(path)
+	if err != nil {
+		reportEntryError(path, err, c.config.Quiet)
+		return err
+	}
+
+	c.addMessage(path, message, info)
+	return nil
+}
+
+// Load Outlook message specified by filePath and create model Entry
+func GetEntriesForMsg(config *EntryConfig, filePath string) (model.Entries, error) {
+	c := newEntryCollector(config)
+	c.root = filePath
+	err := c.addMsgFile(filePath)
+	return c.entries, err
+}
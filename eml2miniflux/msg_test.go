@@ -0,0 +1,110 @@
+package eml2miniflux
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"miniflux.app/model"
+)
+
+// Outlook RSS item of The Go Blog, read and flagged, with the item and channel links as named properties
+const testMsgFile = "testdata/rss_item.msg"
+
+func TestLoadMsg(t *testing.T) {
+	if !IsMsgFile(testMsgFile) {
+		t.Fatalf("%s is not detected as Outlook message", testMsgFile)
+	}
+
+	message, info, err := loadMsg(testMsgFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if message.Subject != "Go 1.20 is released" {
+		t.Errorf("got subject %q", message.Subject)
+	}
+	if message.Text != "Go 1.20 is out." || message.Html != "<html><body><p>Go 1.20 is out.</p></body></html>" {
+		t.Errorf("got text %q, HTML %q", message.Text, message.Html)
+	}
+	if message.ContentBase != "https://go.dev/blog/go1.20" {
+		t.Errorf("got item link %q", message.ContentBase)
+	}
+	if message.MessageId != "tag:blog.golang.org,2013:blog.golang.org/go1.20" {
+		t.Errorf("got message ID %q", message.MessageId)
+	}
+	if want := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC); !message.Date.Equal(want) {
+		t.Errorf("got date %s, want %s", message.Date, want)
+	}
+	if message.Sender == nil || message.Sender.String() != "The Go Blog" {
+		t.Errorf("got sender %v", message.Sender)
+	}
+
+	if !info.HasFlags || !info.Seen || !info.Flagged {
+		t.Errorf("got flags %v, seen %v, flagged %v", info.HasFlags, info.Seen, info.Flagged)
+	}
+	if info.FeedUrl != "https://go.dev/blog/feed.atom" || info.FeedTitle != "The Go Blog" {
+		t.Errorf("got feed %q, %q", info.FeedUrl, info.FeedTitle)
+	}
+	if info.Folder != filepath.Dir(testMsgFile) {
+		t.Errorf("got folder %q", info.Folder)
+	}
+}
+
+func TestGetEntriesForMsg(t *testing.T) {
+	tests := []struct {
+		name    string
+		feedMap string
+		feed    *model.Feed
+		other   *model.Feed
+	}{
+		{
+			name:  "feed by channel link",
+			feed:  &model.Feed{FeedURL: "https://go.dev/blog/feed.atom", Title: "Go"},
+			other: &model.Feed{FeedURL: "https://example.com/feed.xml", Title: "The Go Blog"},
+		},
+		{
+			name:  "feed by channel title",
+			feed:  &model.Feed{FeedURL: "https://blog.golang.org/feed.atom", Title: "The Go Blog"},
+			other: &model.Feed{FeedURL: "https://example.com/feed.xml", Title: "Example"},
+		},
+		{
+			name:    "subscription of the channel comes before the feed map",
+			feedMap: "go.dev => https://example.com/feed.xml\n",
+			feed:    &model.Feed{FeedURL: "https://go.dev/blog/feed.atom", Title: "Go"},
+			other:   &model.Feed{FeedURL: "https://example.com/feed.xml", Title: "Example"},
+		},
+		{
+			name:    "feed map for unknown channel",
+			feedMap: "folder:testdata => https://go.dev/feed.xml\n",
+			feed:    &model.Feed{FeedURL: "https://go.dev/feed.xml", Title: "Go"},
+			other:   &model.Feed{FeedURL: "https://example.com/feed.xml", Title: "Example"},
+		},
+	}
+
+	for _, test := range tests {
+		feedHelper := newTestFeedHelper(t, test.feedMap, test.feed, test.other)
+		config := &EntryConfig{FeedHelper: feedHelper, User: &model.User{ID: 1, DefaultReadingSpeed: 265}}
+
+		entries, err := GetEntriesForMsg(config, testMsgFile)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		if len(entries) != 1 {
+			t.Errorf("%s: got %d entries", test.name, len(entries))
+			continue
+		}
+
+		entry := entries[0]
+		if entry.FeedID != test.feed.ID {
+			t.Errorf("%s: got feed %d, want %d", test.name, entry.FeedID, test.feed.ID)
+		}
+		if entry.URL != "https://go.dev/blog/go1.20" || entry.Title != "Go 1.20 is released" {
+			t.Errorf("%s: got URL %q, title %q", test.name, entry.URL, entry.Title)
+		}
+		if entry.Status != model.EntryStatusRead || !entry.Starred {
+			t.Errorf("%s: got status %s, starred %v", test.name, entry.Status, entry.Starred)
+		}
+	}
+}
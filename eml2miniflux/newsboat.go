@@ -0,0 +1,95 @@
+package eml2miniflux
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"miniflux.app/model"
+)
+
+// IsNewsboatCache determines if a file represented by `filePath`
+// is Newsboat cache, i.e. SQLite database with `rss_feed` and `rss_item` tables
+func IsNewsboatCache(filePath string) bool {
+	return isSqliteWithTables(filePath, "rss_feed", "rss_item")
+}
+
+// Load articles of Newsboat cache
+func loadNewsboatItems(db *sql.DB, fn func(item *feedReaderItem) error) error {
+	columns, err := sqliteColumns(db, "rss_item")
+	if err != nil {
+		return fmt.Errorf("cannot read table rss_item: %s", err)
+	}
+
+	// Columns added by later Newsboat versions are optional
+	contentMimeTypeColumn := "''"
+	if columns["content_mime_type"] {
+		contentMimeTypeColumn = "COALESCE(content_mime_type, '')"
+	}
+	notDeletedCondition := "1"
+	if columns["deleted"] {
+		notDeletedCondition = "COALESCE(deleted, 0) = 0"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			COALESCE(guid, ''), COALESCE(title, ''), COALESCE(author, ''), COALESCE(url, ''),
+			COALESCE(feedurl, ''), COALESCE(pubDate, 0), COALESCE(content, ''), %s,
+			COALESCE(unread, 0) != 0, COALESCE(flags, ''), COALESCE(enclosure_url, ''), COALESCE(enclosure_type, '')
+		FROM rss_item
+		WHERE %s
+		ORDER BY id`, contentMimeTypeColumn, notDeletedCondition)
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return fmt.Errorf("cannot query items: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item feedReaderItem
+		var pubDate int64
+		var contentMimeType string
+		var unread bool
+		var flags string
+
+		err = rows.Scan(&item.guid, &item.title, &item.author, &item.url,
+			&item.feedUrl, &pubDate, &item.content, &contentMimeType,
+			&unread, &flags, &item.enclosureUrl, &item.enclosureType)
+		if err != nil {
+			return fmt.Errorf("cannot read item: %s", err)
+		}
+
+		if pubDate > 0 {
+			item.published = time.Unix(pubDate, 0)
+		}
+		item.plainText = strings.HasPrefix(contentMimeType, "text/plain")
+		item.read = !unread
+		item.tags = newsboatFlagTags(flags)
+
+		err = fn(&item)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Tags of the article flags. Newsboat flags are arbitrary letters set by the user rather than a star,
+// so each of them becomes a tag, ex.: `newsboat-flag-a`.
+func newsboatFlagTags(flags string) []string {
+	var tags []string
+	for _, flag := range flags {
+		if (flag >= 'a' && flag <= 'z') || (flag >= 'A' && flag <= 'Z') {
+			tags = append(tags, "newsboat-flag-"+string(flag))
+		}
+	}
+	return tags
+}
+
+// Load articles from Newsboat cache specified by cachePath and create model Entry for each of them
+func GetEntriesForNewsboat(config *EntryConfig, cachePath string) (model.Entries, error) {
+	return getEntriesForFeedReaderDb(config, "Newsboat cache", cachePath, loadNewsboatItems)
+}
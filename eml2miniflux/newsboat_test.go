@@ -0,0 +1,24 @@
+package eml2miniflux
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewsboatFlagTags(t *testing.T) {
+	tests := []struct {
+		flags string
+		want  string
+	}{
+		{"", ""},
+		{"s", "newsboat-flag-s"},
+		{"aZ", "newsboat-flag-a newsboat-flag-Z"},
+		{"a-1", "newsboat-flag-a"},
+	}
+
+	for _, test := range tests {
+		if got := strings.Join(newsboatFlagTags(test.flags), " "); got != test.want {
+			t.Errorf("newsboatFlagTags(%q) = %q, want %q", test.flags, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package eml2miniflux
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/sg3des/eml"
+)
+
+const (
+	// Text of a footer block is short; a larger block contains the letter itself
+	newsletterFooterMaxLength = 600
+)
+
+var (
+	listHeaderUrlRx = regexp.MustCompile(`<([^>]+)>`)
+	viewOnlineRx    = regexp.MustCompile(`(?i)\b(view|read|open|see)\b.{0,30}\b(online|in (your |a |the )?browser|on the web|web version)\b`)
+	unsubscribeRx   = regexp.MustCompile(`(?i)unsubscribe|manage (your )?(subscription|preferences|email settings)|opt[ -]out`)
+	hiddenImageRx   = regexp.MustCompile(`(?i)display\s*:\s*none|visibility\s*:\s*hidden|(^|[^-])(width|height)\s*:\s*[01](px)?\s*(;|$)`)
+	leadingNumberRx = regexp.MustCompile(`^\s*(\d+)`)
+	// Tracker path segment with a query, ex.: `/open?token=...`, `/track/open.php?u=...`, or a 1x1 GIF
+	trackingPathRx   = regexp.MustCompile(`(?i)/((open|track|pixel|beacon)(\.gif|\.png|\.php)?\?|1x1\.gif(\?|$))`)
+	newsletterBlocks = "p, div, td, tr, table, footer, center, section"
+)
+
+// URLs of a list header, ex.: `List-Archive: <https://example.com/archive>, <mailto:archive@example.com>`
+func listHeaderUrls(value string) []string {
+	var urls []string
+	for _, match := range listHeaderUrlRx.FindAllStringSubmatch(value, -1) {
+		urls = append(urls, strings.TrimSpace(match[1]))
+	}
+	return urls
+}
+
+// URL of the newsletter issue: "view online" link of the letter, otherwise the list archive
+func newsletterUrl(message *eml.Message) string {
+	if len(message.Html) > 0 {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(message.Html))
+		if err == nil {
+			var link string
+			doc.Find("a[href]").EachWithBreak(func(_ int, a *goquery.Selection) bool {
+				href, _ := a.Attr("href")
+				if viewOnlineRx.MatchString(a.Text()) && strings.HasPrefix(href, "http") {
+					link = strings.TrimSpace(href)
+					return false
+				}
+				return true
+			})
+			if len(link) > 0 {
+				return link
+			}
+		}
+	}
+
+	for _, url := range listHeaderUrls(messageHeader(message, "List-Archive")) {
+		if strings.HasPrefix(url, "http") {
+			return url
+		}
+	}
+
+	return ""
+}
+
+// Keys of the newsletter matched with the feed map: list ID, posting address and sender address
+func newsletterKeys(message *eml.Message) []string {
+	var keys []string
+
+	keys = append(keys, listHeaderUrls(messageHeader(message, "List-Id"))...)
+
+	// `List-Post: NO` is used by announcement lists
+	keys = append(keys, listHeaderUrls(messageHeader(message, "List-Post"))...)
+
+	if len(message.From) > 0 && len(message.From[0].Email()) > 0 {
+		keys = append(keys, message.From[0].Email())
+	} else if message.Sender != nil && len(message.Sender.Email()) > 0 {
+		keys = append(keys, message.Sender.Email())
+	}
+
+	return keys
+}
+
+// Determine if the image is a tracking pixel: hidden, 1x1 or loaded from a tracking path
+func isTrackingPixel(img *goquery.Selection) bool {
+	for _, name := range []string{"width", "height"} {
+		if value, ok := img.Attr(name); ok {
+			match := leadingNumberRx.FindStringSubmatch(value)
+			if len(match) >= 2 {
+				if size, err := strconv.Atoi(match[1]); err == nil && size <= 1 {
+					return true
+				}
+			}
+		}
+	}
+
+	if style, ok := img.Attr("style"); ok && hiddenImageRx.MatchString(style) {
+		return true
+	}
+
+	src, _ := img.Attr("src")
+	return trackingPathRx.MatchString(src)
+}
+
+// Length of the text following the selected node up to the end of the body
+func followingTextLength(s *goquery.Selection) int {
+	length := 0
+	for ; s.Length() > 0 && !s.Is("body"); s = s.Parent() {
+		node := s.Nodes[0]
+		following := false
+		s.Parent().Contents().Each(func(_ int, sibling *goquery.Selection) {
+			if following {
+				length += len(strings.TrimSpace(sibling.Text()))
+			} else if sibling.Nodes[0] == node {
+				following = true
+			}
+		})
+	}
+	return length
+}
+
+// Remove the footer around each unsubscribe link of the trailing part of the letter, and tracking pixels;
+// the links to opt out mentioned within the letter itself are kept
+func cleanNewsletter(content string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return content
+	}
+
+	body := doc.Find("body")
+	bodyLength := len(strings.TrimSpace(body.Text()))
+
+	body.Find("img").Each(func(_ int, img *goquery.Selection) {
+		if isTrackingPixel(img) {
+			img.Remove()
+		}
+	})
+
+	body.Find("a").Each(func(_ int, a *goquery.Selection) {
+		href, _ := a.Attr("href")
+		if !unsubscribeRx.MatchString(a.Text()) && !strings.Contains(strings.ToLower(href), "unsubscribe") {
+			return
+		}
+		if followingTextLength(a) > newsletterFooterMaxLength {
+			return
+		}
+
+		// The largest block around the link which is still a footer rather than the letter
+		footer := a
+		for parent := a.Parent(); parent.Length() > 0 && !parent.Is("body"); parent = parent.Parent() {
+			length := len(strings.TrimSpace(parent.Text()))
+			if length > newsletterFooterMaxLength || length*2 > bodyLength {
+				break
+			}
+			if parent.Is(newsletterBlocks) {
+				footer = parent
+			}
+		}
+		footer.Remove()
+	})
+
+	cleaned, err := body.Html()
+	if err != nil {
+		return content
+	}
+
+	return strings.TrimSpace(cleaned)
+}
@@ -0,0 +1,71 @@
+package eml2miniflux
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTrackingPathRx(t *testing.T) {
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{"https://eotrx.substackcdn.com/open?token=abc", true},
+		{"https://list.example.com/track/open.php?u=1&id=2", true},
+		{"https://mail.example.com/pixel.gif?id=1", true},
+		{"https://mail.example.com/beacon?m=1", true},
+		{"https://cdn.example.com/img/1x1.gif", true},
+		{"https://example.com/opengraph/cover.png", false},
+		{"https://example.com/img/pixel-art-hero.jpg", false},
+		{"https://example.com/tracks/race.jpg", false},
+		{"https://example.com/open/source.png", false},
+		{"https://example.com/track/", false},
+	}
+
+	for _, test := range tests {
+		if got := trackingPathRx.MatchString(test.src); got != test.want {
+			t.Errorf("trackingPathRx.MatchString(%q) = %v, want %v", test.src, got, test.want)
+		}
+	}
+}
+
+func TestCleanNewsletter(t *testing.T) {
+	letter := strings.Repeat("Text of the letter. ", 50)
+
+	tests := []struct {
+		name    string
+		content string
+		keep    []string
+		remove  []string
+	}{
+		{
+			name: "footer",
+			content: `<p>` + letter + `</p><img src="https://example.com/opengraph/cover.png">` +
+				`<img src="https://eotrx.substackcdn.com/open?token=abc">` +
+				`<div><p>You received this letter as a subscriber.</p><p><a href="https://example.com/u">Unsubscribe</a></p></div>`,
+			keep:   []string{"Text of the letter.", "opengraph/cover.png"},
+			remove: []string{"open?token", "as a subscriber", "Unsubscribe"},
+		},
+		{
+			name: "link within the letter",
+			content: `<p>Read how to <a href="https://example.com/privacy">opt out of tracking</a> in browsers.</p>` +
+				`<p>` + letter + `</p><p><a href="https://example.com/unsubscribe">Unsubscribe</a></p>`,
+			keep:   []string{"opt out of tracking", "in browsers", "Text of the letter."},
+			remove: []string{"example.com/unsubscribe"},
+		},
+	}
+
+	for _, test := range tests {
+		cleaned := cleanNewsletter(test.content)
+		for _, s := range test.keep {
+			if !strings.Contains(cleaned, s) {
+				t.Errorf("%s: %q is removed", test.name, s)
+			}
+		}
+		for _, s := range test.remove {
+			if strings.Contains(cleaned, s) {
+				t.Errorf("%s: %q is kept", test.name, s)
+			}
+		}
+	}
+}
@@ -0,0 +1,390 @@
+package eml2miniflux
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/a-ilin/eml2miniflux/util"
+	"github.com/sg3des/eml"
+	"miniflux.app/model"
+)
+
+const (
+	operaStoreDir      = "store"
+	operaIndexFile     = "index.ini"
+	operaIndexDir      = "index"
+	operaMessageSuffix = ".mbs"
+
+	// Built-in index of unread messages, when index.ini does not name it
+	operaUnreadIndexID = "0"
+	operaUnreadIndex   = "Unread"
+
+	// Bit of X-Opera-Status flags: message is read
+	operaStatusRead = 0x1
+
+	// Hex digits of M2 message ID leading the long form of X-Opera-Status
+	operaStatusIDDigits = 8
+)
+
+var (
+	// ex.: [Index 300000012]
+	operaIndexSectionRx = regexp.MustCompile(`^\[Index\s+(\d+)\]$`)
+	// ex.: Search Text=https://example.com/rss.xml
+	operaSearchTextRx = regexp.MustCompile(`^Search\d*\s+Text$`)
+	// Message list of the index, ex.: index/300000012.idx or index/index0.idx
+	operaIndexFileRx = regexp.MustCompile(`^(?:index)?(\d+)\.idx$`)
+)
+
+// OperaFeedIndex describes a newsfeed index (feed folder) of Opera Mail
+type OperaFeedIndex struct {
+	ID      string
+	Name    string
+	FeedURL string
+}
+
+// IsOperaMailDir determines if a directory represented by `dirPath`
+// is Opera Mail (M2) directory, i.e. it has `store` subdirectory and `index.ini`
+func IsOperaMailDir(dirPath string) bool {
+	isDir, err := util.IsDirectory(filepath.Join(dirPath, operaStoreDir))
+	if err != nil || !isDir {
+		return false
+	}
+
+	_, err = os.Stat(filepath.Join(dirPath, operaIndexFile))
+	return err == nil
+}
+
+// Indexes of Opera Mail: newsfeeds (feed folders) and the messages of each index
+type operaIndexes struct {
+	feeds    []OperaFeedIndex
+	unreadID string
+
+	// Message IDs by index ID; nil when the index directory is not read
+	messages map[string][]uint32
+}
+
+// Read state and feed folder of the messages, by M2 message ID
+type operaMessageStates struct {
+	unread  map[uint32]bool
+	folders map[uint32]string
+}
+
+// Load indexes of Opera Mail which refer to newsfeeds, i.e. have a feed URL as search text,
+// and the ID of the index of unread messages
+func loadOperaFeedIndexes(mailDir string) ([]OperaFeedIndex, string, error) {
+	file, err := os.Open(filepath.Join(mailDir, operaIndexFile))
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot open index file: %s", err)
+	}
+	defer file.Close()
+
+	var indexes []OperaFeedIndex
+	var current *OperaFeedIndex
+	unreadID := operaUnreadIndexID
+
+	flush := func() {
+		if current != nil && len(current.FeedURL) > 0 {
+			indexes = append(indexes, *current)
+		}
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "[") {
+			flush()
+			if match := operaIndexSectionRx.FindStringSubmatch(line); len(match) >= 2 {
+				current = &OperaFeedIndex{ID: match[1]}
+			}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		if key == "Name" {
+			current.Name = value
+			if strings.EqualFold(value, operaUnreadIndex) {
+				unreadID = current.ID
+			}
+		} else if operaSearchTextRx.MatchString(key) && (strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://")) {
+			current.FeedURL = value
+		}
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		return nil, "", fmt.Errorf("cannot read index file: %s", err)
+	}
+
+	sort.Slice(indexes, func(i, j int) bool {
+		return indexes[i].Name < indexes[j].Name
+	})
+
+	return indexes, unreadID, nil
+}
+
+// Load message IDs of the indexes from `index` directory: each file holds 32-bit little-endian IDs
+func loadOperaIndexMessages(mailDir string) (map[string][]uint32, error) {
+	indexDir := filepath.Join(mailDir, operaIndexDir)
+	if isDir, err := util.IsDirectory(indexDir); err != nil || !isDir {
+		return nil, nil
+	}
+
+	messages := make(map[string][]uint32)
+	err := filepath.Walk(indexDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "FS Error: %s: %s\n", path, err)
+			return nil
+		}
+
+		match := operaIndexFileRx.FindStringSubmatch(strings.ToLower(info.Name()))
+		if info.IsDir() || len(match) < 2 {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("cannot read index: %s", err)
+		}
+
+		id := strings.TrimLeft(match[1], "0")
+		if len(id) == 0 {
+			id = "0"
+		}
+		for i := 0; i+4 <= len(data); i += 4 {
+			messages[id] = append(messages[id], binary.LittleEndian.Uint32(data[i:]))
+		}
+		return nil
+	})
+
+	return messages, err
+}
+
+// Load the newsfeeds and message lists of the indexes of Opera Mail directory
+func loadOperaIndexes(mailDir string) (*operaIndexes, error) {
+	feeds, unreadID, err := loadOperaFeedIndexes(mailDir)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := loadOperaIndexMessages(mailDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &operaIndexes{feeds: feeds, unreadID: unreadID, messages: messages}, nil
+}
+
+// Map message IDs to read state and feed folder; nil when the message lists are unknown
+func (i *operaIndexes) messageStates() *operaMessageStates {
+	if i == nil || i.messages == nil {
+		return nil
+	}
+
+	states := operaMessageStates{unread: make(map[uint32]bool), folders: make(map[uint32]string)}
+	if _, ok := i.messages[i.unreadID]; !ok {
+		// read state is unknown without the index of unread messages
+		states.unread = nil
+	}
+	for _, id := range i.messages[i.unreadID] {
+		states.unread[id] = true
+	}
+
+	// Feeds are sorted by name, so a message of several feed folders goes to the first one
+	for _, feed := range i.feeds {
+		for _, id := range i.messages[feed.ID] {
+			if _, ok := states.folders[id]; !ok {
+				states.folders[id] = feed.Name
+			}
+		}
+	}
+
+	return &states
+}
+
+// M2 message ID: the name of a single message file, ex. `store/.../1234.mbs`,
+// or the leading digits of the long form of `X-Opera-Status` header
+func operaMessageID(message *eml.Message, path string, single bool) (uint32, bool) {
+	if single {
+		if id, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), 10, 32); err == nil {
+			return uint32(id), true
+		}
+	}
+
+	status := messageHeader(message, "X-Opera-Status")
+	if len(status) > operaStatusIDDigits {
+		if id, err := strconv.ParseUint(status[:operaStatusIDDigits], 16, 32); err == nil {
+			return uint32(id), true
+		}
+	}
+
+	return 0, false
+}
+
+// Get read state of Opera message from the index of unread messages, and its feed folder from the feed indexes.
+// Without the indexes the state is taken from `X-Opera-Status` header, or from mbox `Status` header.
+func operaMessageInfo(message *eml.Message, path string, single bool, states *operaMessageStates) *MessageInfo {
+	info := &MessageInfo{Folder: filepath.Dir(path)}
+
+	id, hasID := operaMessageID(message, path, single)
+	if states != nil && hasID {
+		if folder, ok := states.folders[id]; ok {
+			info.FolderPath = folder
+		}
+		if states.unread != nil {
+			info.HasFlags = true
+			info.Seen = !states.unread[id]
+		}
+	}
+
+	if info.HasFlags {
+		// read state is known from the index
+	} else if flags, ok := parseHexFlags(messageHeader(message, "X-Opera-Status")); ok {
+		info.HasFlags = true
+		info.Seen = flags&operaStatusRead != 0
+	} else if status := messageHeader(message, "Status"); len(status) > 0 {
+		info.HasFlags = true
+		info.Seen = strings.ContainsRune(status, 'R')
+	}
+
+	if xStatus := messageHeader(message, "X-Status"); len(xStatus) > 0 {
+		info.Flagged = strings.ContainsRune(xStatus, 'F')
+	}
+
+	return info
+}
+
+// Create entries for messages of Opera store file; it contains either a single message or mbox
+func (c *entryCollector) addOperaFile(path string, states *operaMessageStates) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot read file: %s", err)
+	}
+
+	isMbox := bytes.HasPrefix(raw, mboxFromLine)
+	messageNum := 0
+	addRaw := func(raw []byte) error {
+		messageNum++
+		c.countMessage()
+
+		source := path
+		if messageNum > 1 {
+			source = fmt.Sprintf("%s: message #%d", path, messageNum)
+		}
+
+		message, err := eml.Parse(raw)
+		if err != nil {
+			reportEntryError(source, fmt.Errorf("cannot parse EML: %s", err), c.config.Quiet)
+			return nil
+		}
+
+		c.addMessage(source, &message, operaMessageInfo(&message, path, !isMbox, states))
+		return nil
+	}
+
+	if isMbox {
+		return readMbox(bytes.NewReader(raw), addRaw)
+	}
+
+	return addRaw(raw)
+}
+
+// Feed map pattern matching the messages of the feed folder exactly
+func operaFolderPattern(name string) string {
+	if strings.ContainsAny(name, "*?") {
+		return "folder:re:^" + regexp.QuoteMeta(name) + "$"
+	}
+	return "folder:glob:" + name
+}
+
+// Print feed map lines for newsfeeds of Opera Mail, to be reviewed and used with '-feedmap'.
+// The rules match the feed folders of the messages, or the host of the feed when the index messages are unknown.
+func printOperaFeedMapSuggestions(indexes *operaIndexes, feedHelper *FeedHelper) {
+	if indexes == nil || len(indexes.feeds) == 0 {
+		return
+	}
+
+	fmt.Fprintf(os.Stdout, "Feed map suggestions for Opera newsfeeds:\n")
+	for _, index := range indexes.feeds {
+		fmt.Fprintf(os.Stdout, "  # %s: %s\n", index.Name, index.FeedURL)
+
+		var feed *model.Feed
+		if feedHelper != nil {
+			feed = feedHelper.FeedByURL(index.FeedURL)
+		}
+
+		pattern := operaFolderPattern(index.Name)
+		if indexes.messages == nil {
+			pattern = urlHost(index.FeedURL)
+			if feed != nil && len(urlHost(feed.SiteURL)) > 0 {
+				pattern = urlHost(feed.SiteURL)
+			}
+		}
+
+		if feed != nil {
+			fmt.Fprintf(os.Stdout, "  %s => %s\n", pattern, feed.FeedURL)
+		} else {
+			fmt.Fprintf(os.Stdout, "  # feed is not found in Miniflux\n")
+			fmt.Fprintf(os.Stdout, "  # %s => none\n", pattern)
+		}
+	}
+}
+
+// Load messages from Opera Mail (M2) directory specified by mailDir and create model Entry for each of them.
+// Newsfeeds of the directory are printed as feed map suggestions.
+func GetEntriesForOpera(config *EntryConfig, mailDir string) (model.Entries, error) {
+	c := newEntryCollector(config)
+	c.root = mailDir
+
+	indexes, err := loadOperaIndexes(mailDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot load Opera indexes: %s\n", err)
+	}
+
+	if indexes != nil {
+		for _, index := range indexes.feeds {
+			fmt.Fprintf(os.Stdout, "Found Opera newsfeed '%s': %s\n", index.Name, index.FeedURL)
+		}
+	}
+	states := indexes.messageStates()
+
+	err = filepath.Walk(filepath.Join(mailDir, operaStoreDir), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "FS Error: %s: %s\n", path, err)
+			return nil
+		}
+
+		if !info.IsDir() && strings.HasSuffix(strings.ToLower(path), operaMessageSuffix) {
+			err = c.addOperaFile(path, states)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error on processing file: %s: %s\n", path, err)
+			}
+		}
+
+		return nil
+	})
+	fmt.Fprintf(os.Stdout, "Reading Opera store completed. Processed messages: %d\n", c.entryCounter)
+
+	printOperaFeedMapSuggestions(indexes, config.FeedHelper)
+
+	return c.entries, err
+}
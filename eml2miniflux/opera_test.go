@@ -0,0 +1,87 @@
+package eml2miniflux
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sg3des/eml"
+)
+
+func writeOperaIndex(t *testing.T, path string, ids ...uint32) {
+	data := make([]byte, 4*len(ids))
+	for i, id := range ids {
+		binary.LittleEndian.PutUint32(data[4*i:], id)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOperaMessageInfo(t *testing.T) {
+	mailDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(mailDir, operaIndexFile), []byte(`[Index 7]
+Name=Unread
+
+[Index 300000001]
+Name=Go Blog
+Search Text=https://go.dev/blog/feed.atom
+
+[Index 300000002]
+Name=XKCD
+Search Text=https://xkcd.com/rss.xml
+`), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeOperaIndex(t, filepath.Join(mailDir, operaIndexDir, "7.idx"), 2, 12)
+	writeOperaIndex(t, filepath.Join(mailDir, operaIndexDir, "300000001.idx"), 1, 2)
+	writeOperaIndex(t, filepath.Join(mailDir, operaIndexDir, "sub", "index300000002.idx"), 11, 12)
+
+	indexes, err := loadOperaIndexes(mailDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	states := indexes.messageStates()
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		single bool
+		seen   bool
+		folder string
+	}{
+		{name: "read by file name", path: "store/2010/01/01/1.mbs", single: true, seen: true, folder: "Go Blog"},
+		{name: "unread by file name", path: "store/2010/01/01/2.mbs", single: true, folder: "Go Blog"},
+		{name: "read by status header", path: "store/2010/01/01.mbs", header: "0000000b0000000000000000", seen: true, folder: "XKCD"},
+		{name: "unread by status header", path: "store/2010/01/01.mbs", header: "0000000c0000000000000000", folder: "XKCD"},
+		{name: "name of mbox file is not ID", path: "store/2010/01/2.mbs", header: "0000000b0000000000000000", seen: true, folder: "XKCD"},
+		{name: "not in feed folder", path: "store/2010/01/01/5.mbs", single: true, seen: true},
+	}
+
+	for _, test := range tests {
+		message := eml.Message{}
+		if len(test.header) > 0 {
+			message.FullHeaders = append(message.FullHeaders, eml.Header{Key: "X-Opera-Status", Value: test.header})
+		}
+
+		info := operaMessageInfo(&message, filepath.Join(mailDir, test.path), test.single, states)
+		if !info.HasFlags || info.Seen != test.seen || info.FolderPath != test.folder {
+			t.Errorf("%s: got flags %v, seen %v, folder %q", test.name, info.HasFlags, info.Seen, info.FolderPath)
+		}
+	}
+}
+
+func TestOperaMessageInfoWithoutIndex(t *testing.T) {
+	message := eml.Message{}
+	message.FullHeaders = []eml.Header{{Key: "Status", Value: "RO"}}
+	info := operaMessageInfo(&message, "store/1.mbs", true, nil)
+	if !info.HasFlags || !info.Seen || len(info.FolderPath) > 0 {
+		t.Errorf("got flags %v, seen %v, folder %q", info.HasFlags, info.Seen, info.FolderPath)
+	}
+}
@@ -0,0 +1,70 @@
+package eml2miniflux
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"miniflux.app/model"
+)
+
+var (
+	// QuiteRSS keeps dates as text in UTC, normally in ISO format without time zone
+	quiteRssDateLayouts = []string{
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05",
+		time.RFC3339,
+	}
+)
+
+// IsQuiteRSSDatabase determines if a file represented by `filePath`
+// is QuiteRSS database, i.e. SQLite database with `feeds` and `news` tables
+func IsQuiteRSSDatabase(filePath string) bool {
+	return isSqliteWithTables(filePath, "feeds", "news")
+}
+
+// Load news of QuiteRSS database; deleted news (in the trash or removed) are skipped.
+// Any non-zero `read` value means the news is read.
+func loadQuiteRSSItems(db *sql.DB, fn func(item *feedReaderItem) error) error {
+	rows, err := db.Query(`
+		SELECT
+			COALESCE(n.guid, ''), COALESCE(n.title, ''), COALESCE(n.author_name, ''),
+			COALESCE(NULLIF(n.link_href, ''), n.link_alternate, ''), COALESCE(f.xmlUrl, ''),
+			COALESCE(NULLIF(n.published, ''), n.received, ''), COALESCE(NULLIF(n.content, ''), n.description, ''),
+			COALESCE(n.read, 0) != 0, COALESCE(n.starred, 0) != 0, COALESCE(n.enclosure_url, ''), COALESCE(n.enclosure_type, '')
+		FROM news n
+		LEFT JOIN feeds f ON f.id = n.feedId
+		WHERE COALESCE(n.deleted, 0) = 0
+		ORDER BY n.id`)
+	if err != nil {
+		return fmt.Errorf("cannot query news: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item feedReaderItem
+		var published string
+
+		err = rows.Scan(&item.guid, &item.title, &item.author,
+			&item.url, &item.feedUrl,
+			&published, &item.content,
+			&item.read, &item.starred, &item.enclosureUrl, &item.enclosureType)
+		if err != nil {
+			return fmt.Errorf("cannot read news: %s", err)
+		}
+
+		item.published = parseTimeLayouts(published, quiteRssDateLayouts)
+
+		err = fn(&item)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Load news from QuiteRSS database specified by dbPath and create model Entry for each of them
+func GetEntriesForQuiteRSS(config *EntryConfig, dbPath string) (model.Entries, error) {
+	return getEntriesForFeedReaderDb(config, "QuiteRSS database", dbPath, loadQuiteRSSItems)
+}
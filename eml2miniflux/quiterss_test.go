@@ -0,0 +1,76 @@
+package eml2miniflux
+
+import (
+	"testing"
+	"time"
+
+	"miniflux.app/crypto"
+	"miniflux.app/model"
+)
+
+func TestGetEntriesForQuiteRSS(t *testing.T) {
+	dbPath := testSqliteDatabase(t,
+		`CREATE TABLE feeds (id INTEGER PRIMARY KEY, text TEXT, title TEXT, xmlUrl TEXT, htmlUrl TEXT)`,
+		`CREATE TABLE news (id INTEGER PRIMARY KEY, feedId INTEGER, guid TEXT, description TEXT, content TEXT,
+			title TEXT, published TEXT, received TEXT, author_name TEXT, link_href TEXT, link_alternate TEXT,
+			enclosure_url TEXT, enclosure_type TEXT, read INTEGER, starred INTEGER, deleted INTEGER)`,
+		`INSERT INTO feeds (id, title, xmlUrl) VALUES (1, 'The Go Blog', 'https://go.dev/blog/feed.atom'),
+			(2, 'Example', 'https://blog.example.com/rss'), (3, 'Unknown', 'https://unknown.example.org/feed')`,
+		// read (QuiteRSS writes 2 once the news is shown) and starred news with enclosure
+		`INSERT INTO news (id, feedId, guid, description, content, title, published, received, author_name,
+			link_href, enclosure_url, enclosure_type, read, starred, deleted)
+			VALUES (1, 1, 'tag:go.dev,2023:go1.20', 'Summary', '<p>Go 1.20 is out.</p>', 'Go 1.20 is released',
+			'2023-01-02T03:04:05', '2023-01-02T04:00:00', 'Robert Griesemer', 'https://go.dev/blog/go1.20',
+			'https://go.dev/blog/go1.20.mp3', 'audio/mpeg', 2, 1, 0)`,
+		// alternate link, description and date of receiving are used when the others are empty
+		`INSERT INTO news (id, feedId, guid, description, content, title, published, received,
+			link_href, link_alternate, read, starred, deleted)
+			VALUES (2, 2, '', '<p>Text</p>', '', 'Unread', '', '2023-01-03 04:05:06', '',
+			'https://blog.example.com/unread', 0, 0, 0)`,
+		`INSERT INTO news (id, feedId, guid, title, link_href, read, deleted)
+			VALUES (3, 1, 'tag:go.dev,2023:deleted', 'Deleted', 'https://go.dev/blog/deleted', 1, 1)`,
+		`INSERT INTO news (id, feedId, guid, title, link_href, read, deleted)
+			VALUES (4, 3, '', 'Unknown feed', 'https://unknown.example.org/post', 0, 0)`)
+
+	if !IsQuiteRSSDatabase(dbPath) {
+		t.Fatalf("%s is not detected as QuiteRSS database", dbPath)
+	}
+
+	goFeed := &model.Feed{FeedURL: "https://go.dev/blog/feed.atom"}
+	exampleFeed := &model.Feed{FeedURL: "https://blog.example.com/rss"}
+	feedHelper := newTestFeedHelper(t, "", goFeed, exampleFeed)
+	config := &EntryConfig{FeedHelper: feedHelper, User: &model.User{ID: 1}, Quiet: true}
+
+	entries, err := GetEntriesForQuiteRSS(config, dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+
+	first := entries[0]
+	if first.URL != "https://go.dev/blog/go1.20" || first.Hash != crypto.Hash("tag:go.dev,2023:go1.20") || first.FeedID != goFeed.ID {
+		t.Errorf("news #1: got URL %q, hash %s, feed %d", first.URL, first.Hash, first.FeedID)
+	}
+	if first.Status != model.EntryStatusRead || !first.Starred {
+		t.Errorf("news #1: got status %s, starred %v", first.Status, first.Starred)
+	}
+	if first.Content != "<p>Go 1.20 is out.</p>" || !first.Date.Equal(time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("news #1: got content %q, date %s", first.Content, first.Date)
+	}
+	if len(first.Enclosures) != 1 || first.Enclosures[0].URL != "https://go.dev/blog/go1.20.mp3" || first.Enclosures[0].MimeType != "audio/mpeg" {
+		t.Errorf("news #1: got enclosures %v", first.Enclosures)
+	}
+
+	second := entries[1]
+	if second.URL != "https://blog.example.com/unread" || second.Hash != crypto.Hash("https://blog.example.com/unread") || second.FeedID != exampleFeed.ID {
+		t.Errorf("news #2: got URL %q, hash %s, feed %d", second.URL, second.Hash, second.FeedID)
+	}
+	if second.Status != model.EntryStatusUnread || second.Starred {
+		t.Errorf("news #2: got status %s, starred %v", second.Status, second.Starred)
+	}
+	if second.Content != "<p>Text</p>" || !second.Date.Equal(time.Date(2023, 1, 3, 4, 5, 6, 0, time.UTC)) {
+		t.Errorf("news #2: got content %q, date %s", second.Content, second.Date)
+	}
+}
@@ -0,0 +1,165 @@
+package eml2miniflux
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"miniflux.app/model"
+)
+
+const (
+	// Amount of path prefixes listed for a host of the skeleton map
+	scanMaxPrefixes = 10
+)
+
+// Unmatched entries of the same path prefix (the first segment of the path)
+type scanPrefix struct {
+	prefix  string
+	entries int
+	sample  string
+}
+
+// Unmatched entries of the same host, without `www.` prefix
+type scanHost struct {
+	host     string
+	entries  int
+	prefixes map[string]*scanPrefix
+}
+
+// URLs of the entries unmatched by the feed map
+type unmatchedScan struct {
+	hosts      map[string]*scanHost
+	withoutUrl int
+}
+
+// EnableScan makes the helper collect URLs of the entries which match no feed, for the skeleton feed map
+func (h *FeedHelper) EnableScan() {
+	h.unmatched = &unmatchedScan{hosts: make(map[string]*scanHost)}
+}
+
+// Remember the URL of the entry which matches no feed
+func (h *FeedHelper) recordUnmatched(entryUrl string) {
+	if h.unmatched == nil {
+		return
+	}
+
+	host, path := siteHostPath(entryUrl)
+	if len(host) == 0 {
+		h.unmatched.withoutUrl++
+		return
+	}
+
+	group, ok := h.unmatched.hosts[host]
+	if !ok {
+		group = &scanHost{host: host, prefixes: make(map[string]*scanPrefix)}
+		h.unmatched.hosts[host] = group
+	}
+	group.entries++
+
+	prefix := "/"
+	if segments := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2); len(segments) > 1 {
+		// the last segment is the article itself rather than a section of the site
+		prefix = "/" + segments[0] + "/"
+	}
+
+	if _, ok := group.prefixes[prefix]; !ok {
+		group.prefixes[prefix] = &scanPrefix{prefix: prefix, sample: entryUrl}
+	}
+	group.prefixes[prefix].entries++
+}
+
+// Feeds which site or feed URL is on the host, its parent domain or subdomain
+func (h *FeedHelper) scanCandidates(host string) []*model.Feed {
+	var candidates []*model.Feed
+	for _, feed := range h.feedsId {
+		for _, feedUrl := range []string{feed.SiteURL, feed.FeedURL} {
+			feedHost := urlHost(feedUrl)
+			if len(feedHost) > 0 && (feedHost == host || strings.HasSuffix(host, "."+feedHost) || strings.HasSuffix(feedHost, "."+host)) {
+				candidates = append(candidates, feed)
+				break
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].FeedURL < candidates[j].FeedURL
+	})
+
+	return candidates
+}
+
+// ScanMap returns the skeleton feed map for the unmatched entries, in `LoadMap` syntax:
+// a rule for the hosts with a single candidate feed, commented candidates otherwise,
+// and a commented `none` rule for the hosts without candidates
+func (h *FeedHelper) ScanMap() string {
+	var feedMap strings.Builder
+	feedMap.WriteString("# Feed map skeleton of the entries matching no feed; review it before using with '-feedmap'\n")
+
+	if h.unmatched == nil {
+		return feedMap.String()
+	}
+
+	if h.unmatched.withoutUrl > 0 {
+		fmt.Fprintf(&feedMap, "# Entries without URL: %d; match them by message fields, ex.: from:, subject:, folder:\n", h.unmatched.withoutUrl)
+	}
+
+	var hosts []*scanHost
+	for _, group := range h.unmatched.hosts {
+		hosts = append(hosts, group)
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		if hosts[i].entries != hosts[j].entries {
+			return hosts[i].entries > hosts[j].entries
+		}
+		return hosts[i].host < hosts[j].host
+	})
+
+	for _, group := range hosts {
+		fmt.Fprintf(&feedMap, "\n# %s: %d entries\n", group.host, group.entries)
+
+		var prefixes []*scanPrefix
+		for _, prefix := range group.prefixes {
+			prefixes = append(prefixes, prefix)
+		}
+		sort.Slice(prefixes, func(i, j int) bool {
+			if prefixes[i].entries != prefixes[j].entries {
+				return prefixes[i].entries > prefixes[j].entries
+			}
+			return prefixes[i].prefix < prefixes[j].prefix
+		})
+		for i, prefix := range prefixes {
+			if i == scanMaxPrefixes {
+				fmt.Fprintf(&feedMap, "#   ... %d more path prefixes\n", len(prefixes)-scanMaxPrefixes)
+				break
+			}
+			fmt.Fprintf(&feedMap, "#   %s: %d, ex.: %s\n", prefix.prefix, prefix.entries, prefix.sample)
+		}
+
+		candidates := h.scanCandidates(group.host)
+		switch len(candidates) {
+		case 0:
+			fmt.Fprintf(&feedMap, "# No candidate feed\n")
+			fmt.Fprintf(&feedMap, "# host:%s => none\n", group.host)
+		case 1:
+			fmt.Fprintf(&feedMap, "host:%s => %s\n", group.host, candidates[0].FeedURL)
+		default:
+			fmt.Fprintf(&feedMap, "# Candidate feeds:\n")
+			for _, feed := range candidates {
+				fmt.Fprintf(&feedMap, "# host:%s => %s\n", group.host, feed.FeedURL)
+			}
+		}
+	}
+
+	return feedMap.String()
+}
+
+// WriteScanMap writes the skeleton feed map for the unmatched entries to the file
+func (h *FeedHelper) WriteScanMap(fileName string) error {
+	err := os.WriteFile(fileName, []byte(h.ScanMap()), 0666)
+	if err != nil {
+		return fmt.Errorf("cannot write feed map file: %s", err)
+	}
+	return nil
+}
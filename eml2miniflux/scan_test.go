@@ -0,0 +1,65 @@
+package eml2miniflux
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"miniflux.app/model"
+)
+
+func TestScanMapSingleEML(t *testing.T) {
+	feedHelper := newTestFeedHelper(t, "", &model.Feed{FeedURL: "https://go.dev/blog/feed.atom", SiteURL: "https://go.dev/blog/"})
+	feedHelper.EnableScan()
+
+	path := filepath.Join(t.TempDir(), "a.eml")
+	if err := os.WriteFile(path, testFeedMessage("Post", "https://www.go.dev/blog/post"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	config := &EntryConfig{FeedHelper: feedHelper, User: &model.User{ID: 1}, Quiet: true}
+	entries, err := GetEntriesForEML(config, path)
+	if err != nil {
+		t.Fatalf("unmatched message fails the scan: %s", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("got %d entries, want none", len(entries))
+	}
+
+	want := "# go.dev: 1 entries\n#   /blog/: 1, ex.: https://www.go.dev/blog/post\nhost:go.dev => https://go.dev/blog/feed.atom\n"
+	if feedMap := feedHelper.ScanMap(); !strings.Contains(feedMap, want) {
+		t.Errorf("got feed map:\n%s\nwant it to contain:\n%s", feedMap, want)
+	}
+}
+
+func TestScanMapFeedReaderItems(t *testing.T) {
+	feedHelper := newTestFeedHelper(t, "", &model.Feed{FeedURL: "https://example.com/feed.xml"})
+	feedHelper.EnableScan()
+
+	user := &model.User{ID: 1}
+	for _, feedUrl := range []string{"http://old.example.com/rss", "http://old.example.com/rss", "https://other.org/atom.xml"} {
+		var entry model.Entry
+		if _, err := assignUserFeedByFeedUrl(&entry, feedUrl, user, feedHelper, nil); err == nil {
+			t.Fatalf("feed URL %s is matched", feedUrl)
+		}
+	}
+
+	var entry model.Entry
+	if _, err := assignUserFeedByFeedUrl(&entry, "https://example.com/feed.xml", user, feedHelper, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	feedMap := feedHelper.ScanMap()
+	for _, want := range []string{
+		"# old.example.com: 2 entries\n#   /: 2, ex.: http://old.example.com/rss\nhost:old.example.com => https://example.com/feed.xml\n",
+		"# other.org: 1 entries\n#   /: 1, ex.: https://other.org/atom.xml\n# No candidate feed\n# host:other.org => none\n",
+	} {
+		if !strings.Contains(feedMap, want) {
+			t.Errorf("got feed map:\n%s\nwant it to contain:\n%s", feedMap, want)
+		}
+	}
+	if strings.Contains(feedMap, "# example.com:") {
+		t.Errorf("matched feed URL is in the feed map:\n%s", feedMap)
+	}
+}
@@ -0,0 +1,99 @@
+package eml2miniflux
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+var (
+	sqliteHeader = []byte("SQLite format 3\x00")
+)
+
+// Determine if a file represented by `filePath` is SQLite database by its header
+func isSqliteFile(filePath string) bool {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	header := make([]byte, len(sqliteHeader))
+	_, err = io.ReadFull(file, header)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(header, sqliteHeader)
+}
+
+// Open SQLite database read-only, so the database of a running application is not modified
+func openSqlite(filePath string) (*sql.DB, error) {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.ToSlash(abs)
+	if !strings.HasPrefix(path, "/") {
+		// Windows drive letter
+		path = "/" + path
+	}
+
+	dsn := (&url.URL{Scheme: "file", Path: path, RawQuery: "mode=ro"}).String()
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open database: %s", err)
+	}
+
+	return db, nil
+}
+
+// Columns of SQLite table; empty when the table does not exist
+func sqliteColumns(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+
+	return columns, rows.Err()
+}
+
+// Determine if a file represented by `filePath` is SQLite database having all the tables
+func isSqliteWithTables(filePath string, tables ...string) bool {
+	if !isSqliteFile(filePath) {
+		return false
+	}
+
+	db, err := openSqlite(filePath)
+	if err != nil {
+		return false
+	}
+	defer db.Close()
+
+	for _, table := range tables {
+		columns, err := sqliteColumns(db, table)
+		if err != nil || len(columns) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
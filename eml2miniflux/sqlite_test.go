@@ -0,0 +1,48 @@
+package eml2miniflux
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// SQLite database in a temporary directory created by the statements
+func testSqliteDatabase(t *testing.T, statements ...string) string {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("%s: %s", statement, err)
+		}
+	}
+
+	return dbPath
+}
+
+func TestIsSqliteWithTables(t *testing.T) {
+	dbPath := testSqliteDatabase(t,
+		`CREATE TABLE feeds (id INTEGER PRIMARY KEY, xmlUrl TEXT)`,
+		`CREATE TABLE news (id INTEGER PRIMARY KEY, feedId INTEGER)`)
+
+	textPath := filepath.Join(t.TempDir(), "test.txt")
+	if err := os.WriteFile(textPath, []byte("SQLite format 2"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	if !isSqliteWithTables(dbPath, "feeds", "news") {
+		t.Errorf("tables of the database are not found")
+	}
+	if isSqliteWithTables(dbPath, "feeds", "items") {
+		t.Errorf("missing table is found")
+	}
+	if isSqliteWithTables(textPath) {
+		t.Errorf("text file is detected as database")
+	}
+}
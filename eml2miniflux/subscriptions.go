@@ -0,0 +1,199 @@
+package eml2miniflux
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"miniflux.app/model"
+)
+
+const (
+	thunderbirdSubscriptionsJson = "feeds.json"
+	thunderbirdSubscriptionsRdf  = "feeds.rdf"
+)
+
+// Feed subscription of Thunderbird RSS account
+type thunderbirdSubscription struct {
+	URL        string `json:"url"`
+	DestFolder string `json:"destFolder"`
+}
+
+// Subscriptions of Thunderbird versions prior to 68 (feeds.rdf)
+type thunderbirdRdfSubscriptions struct {
+	Descriptions []struct {
+		About      string `xml:"about,attr"`
+		DestFolder struct {
+			Resource string `xml:"resource,attr"`
+		} `xml:"destFolder"`
+	} `xml:"Description"`
+}
+
+func parseThunderbirdSubscriptionsJson(data []byte) ([]thunderbirdSubscription, error) {
+	var subscriptions []thunderbirdSubscription
+	err := json.Unmarshal(data, &subscriptions)
+	if err != nil {
+		return nil, fmt.Errorf("cannot unmarshal JSON: %s", err)
+	}
+
+	return subscriptions, nil
+}
+
+func parseThunderbirdSubscriptionsRdf(data []byte) ([]thunderbirdSubscription, error) {
+	var rdf thunderbirdRdfSubscriptions
+	err := xml.Unmarshal(data, &rdf)
+	if err != nil {
+		return nil, fmt.Errorf("cannot unmarshal RDF: %s", err)
+	}
+
+	var subscriptions []thunderbirdSubscription
+	for _, desc := range rdf.Descriptions {
+		if len(desc.About) > 0 && len(desc.DestFolder.Resource) > 0 {
+			subscriptions = append(subscriptions, thunderbirdSubscription{
+				URL:        desc.About,
+				DestFolder: desc.DestFolder.Resource,
+			})
+		}
+	}
+
+	return subscriptions, nil
+}
+
+// Convert folder URI to the path of folder file within account directory,
+// ex.: `mailbox://nobody@Feeds/News/xkcd` => `<accountDir>/News.sbd/xkcd`
+func thunderbirdFolderPath(accountDir string, folderUri string) (string, error) {
+	// The host is the account name, escaped as the path is, ex.: `mailbox://nobody@News%20%26%20Blogs/Go`;
+	// URL parser refuses such hosts, so only the path is taken
+	_, rest, _ := strings.Cut(folderUri, "://")
+	_, folderPath, _ := strings.Cut(rest, "/")
+	folderPath, err := url.PathUnescape(folderPath)
+	if err != nil {
+		return "", err
+	}
+
+	parts := strings.Split(strings.Trim(folderPath, "/"), "/")
+	if len(parts) == 0 || len(parts[0]) == 0 {
+		return "", fmt.Errorf("folder is missing")
+	}
+
+	for i := 0; i < len(parts)-1; i++ {
+		parts[i] += thunderbirdSubfolderSuffix
+	}
+
+	return filepath.Join(append([]string{accountDir}, parts...)...), nil
+}
+
+// Key of the folder lookup
+func folderKey(folderPath string) string {
+	if abs, err := filepath.Abs(folderPath); err == nil {
+		return abs
+	}
+	return filepath.Clean(folderPath)
+}
+
+// LoadSubscriptions loads feed subscriptions of Thunderbird RSS account from feeds.json or feeds.rdf.
+// The file must be located within the account directory, as folders are resolved relative to it.
+func (h *FeedHelper) LoadSubscriptions(fileName string) error {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return fmt.Errorf("cannot read subscriptions file: %s", err)
+	}
+
+	var subscriptions []thunderbirdSubscription
+	if strings.HasSuffix(strings.ToLower(fileName), ".rdf") {
+		subscriptions, err = parseThunderbirdSubscriptionsRdf(data)
+	} else {
+		subscriptions, err = parseThunderbirdSubscriptionsJson(data)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot parse subscriptions file: %s: %s", fileName, err)
+	}
+
+	if h.feedsFolder == nil {
+		h.feedsFolder = make(map[string][]string)
+	}
+
+	accountDir := filepath.Dir(fileName)
+	for _, subscription := range subscriptions {
+		folderPath, err := thunderbirdFolderPath(accountDir, subscription.DestFolder)
+		if err != nil {
+			return fmt.Errorf("wrong folder of subscription %s: %s: %s", subscription.URL, subscription.DestFolder, err)
+		}
+
+		if h.FeedByURL(subscription.URL) == nil {
+			fmt.Fprintf(os.Stderr, "Subscription is not found in Miniflux: %s (folder %s)\n", subscription.URL, ThunderbirdFolderName(accountDir, folderPath))
+		}
+
+		key := folderKey(folderPath)
+		h.feedsFolder[key] = append(h.feedsFolder[key], subscription.URL)
+	}
+
+	return nil
+}
+
+// LoadProfileSubscriptions loads feed subscriptions of the RSS accounts of Thunderbird profile
+func (h *FeedHelper) LoadProfileSubscriptions(accounts []*ThunderbirdAccount) error {
+	for _, account := range accounts {
+		for _, name := range []string{thunderbirdSubscriptionsJson, thunderbirdSubscriptionsRdf} {
+			fileName := filepath.Join(account.Directory, name)
+			if _, err := os.Stat(fileName); err != nil {
+				continue
+			}
+
+			err := h.LoadSubscriptions(fileName)
+			if err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	return nil
+}
+
+// FeedForFolder finds the feed subscribed to the folder containing the entry.
+// When several feeds deliver items into the folder, the one with the entry host is chosen.
+// Nil is returned when there is no such feed.
+func (h *FeedHelper) FeedForFolder(folderPath string, entryUrl string) *model.Feed {
+	feedUrls, ok := h.feedsFolder[folderKey(folderPath)]
+	if !ok {
+		return nil
+	}
+
+	var candidates []*model.Feed
+	for _, feedUrl := range feedUrls {
+		if feed := h.FeedByURL(feedUrl); feed != nil {
+			candidates = append(candidates, feed)
+		}
+	}
+
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+
+	entryHost := urlHost(entryUrl)
+	if len(entryHost) == 0 {
+		return nil
+	}
+
+	for _, feed := range candidates {
+		if urlHost(feed.SiteURL) == entryHost || urlHost(feed.FeedURL) == entryHost {
+			return feed
+		}
+	}
+
+	return nil
+}
+
+// Host of the URL without `www.` prefix
+func urlHost(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
@@ -0,0 +1,112 @@
+package eml2miniflux
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"miniflux.app/model"
+)
+
+// Subscriptions of Thunderbird 68 and later
+const testSubscriptionsJson = `[
+  {"url": "https://go.dev/blog/feed.atom", "destFolder": "mailbox://nobody@Feeds/Go", "title": "The Go Blog"},
+  {"url": "https://xkcd.com/rss.xml", "destFolder": "mailbox://nobody@Feeds/News/Comics"},
+  {"url": "https://blog.example.com/rss", "destFolder": "mailbox://nobody@Feeds/News"},
+  {"url": "https://other.example.org/rss", "destFolder": "mailbox://nobody@Feeds/News"},
+  {"url": "https://unsubscribed.example.org/rss", "destFolder": "mailbox://nobody@Feeds/Old"}
+]`
+
+// Subscriptions of Thunderbird prior to 68
+const testSubscriptionsRdf = `<?xml version="1.0"?>
+<RDF:RDF xmlns:NC="http://home.netscape.com/NC-rdf#"
+         xmlns:fz="urn:forumzilla:"
+         xmlns:dc="http://purl.org/dc/elements/1.1/"
+         xmlns:RDF="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <RDF:Description RDF:about="https://www.example.net/feed"
+                   dc:title="Example"
+                   fz:quickMode="false">
+    <fz:destFolder RDF:resource="mailbox://nobody@News%20%26%20Blogs/Example%20Blog"/>
+  </RDF:Description>
+  <RDF:Description RDF:about="urn:forumzilla:root">
+    <fz:feeds RDF:resource="rdf:#$bjlbk"/>
+  </RDF:Description>
+</RDF:RDF>`
+
+func TestFeedForFolder(t *testing.T) {
+	profile := t.TempDir()
+	accounts := []*ThunderbirdAccount{
+		{Key: "server2", Name: "Feeds", Directory: filepath.Join(profile, "Mail", "Feeds")},
+		{Key: "server3", Name: "News & Blogs", Directory: filepath.Join(profile, "Mail", "News & Blogs")},
+		{Key: "server4", Name: "Without subscriptions", Directory: filepath.Join(profile, "Mail", "Empty")},
+	}
+	for _, account := range accounts {
+		if err := os.MkdirAll(account.Directory, 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(accounts[0].Directory, "feeds.json"), []byte(testSubscriptionsJson), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(accounts[1].Directory, "feeds.rdf"), []byte(testSubscriptionsRdf), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	goFeed := &model.Feed{FeedURL: "https://go.dev/blog/feed.atom"}
+	xkcdFeed := &model.Feed{FeedURL: "https://xkcd.com/rss.xml"}
+	blogFeed := &model.Feed{FeedURL: "https://blog.example.com/rss", SiteURL: "https://www.blog.example.com/"}
+	otherFeed := &model.Feed{FeedURL: "https://other.example.org/rss"}
+	netFeed := &model.Feed{FeedURL: "https://www.example.net/feed"}
+	feedHelper := newTestFeedHelper(t, "", goFeed, xkcdFeed, blogFeed, otherFeed, netFeed)
+
+	if err := feedHelper.LoadProfileSubscriptions(accounts); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		folder   string
+		entryUrl string
+		want     *model.Feed
+	}{
+		{name: "single feed", folder: "Mail/Feeds/Go", entryUrl: "https://example.com/unrelated", want: goFeed},
+		{name: "subfolder", folder: "Mail/Feeds/News.sbd/Comics", want: xkcdFeed},
+		{name: "several feeds, by site host", folder: "Mail/Feeds/News", entryUrl: "https://blog.example.com/post", want: blogFeed},
+		{name: "several feeds, by feed host", folder: "Mail/Feeds/News", entryUrl: "https://www.other.example.org/post", want: otherFeed},
+		{name: "several feeds, unknown host", folder: "Mail/Feeds/News", entryUrl: "https://example.com/post"},
+		{name: "several feeds, without URL", folder: "Mail/Feeds/News"},
+		{name: "feed not in Miniflux", folder: "Mail/Feeds/Old", entryUrl: "https://unsubscribed.example.org/post"},
+		{name: "folder without subscription", folder: "Mail/Feeds/Trash"},
+		{name: "RDF subscription with escaped account", folder: "Mail/News & Blogs/Example Blog", want: netFeed},
+	}
+
+	for _, test := range tests {
+		got := feedHelper.FeedForFolder(filepath.Join(profile, filepath.FromSlash(test.folder)), test.entryUrl)
+		if got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestLoadSubscriptionsErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "feeds.json", content: `{"url": "https://go.dev/blog/feed.atom"}`},
+		{name: "feeds.rdf", content: `<RDF:RDF`},
+		{name: "folder.json", content: `[{"url": "https://go.dev/blog/feed.atom", "destFolder": "mailbox://nobody@Feeds"}]`},
+		{name: "escape.json", content: `[{"url": "https://go.dev/blog/feed.atom", "destFolder": "mailbox://nobody@Feeds/Go%2"}]`},
+	}
+
+	for _, test := range tests {
+		fileName := filepath.Join(dir, test.name)
+		if err := os.WriteFile(fileName, []byte(test.content), 0666); err != nil {
+			t.Fatal(err)
+		}
+		if err := newTestFeedHelper(t, "").LoadSubscriptions(fileName); err == nil {
+			t.Errorf("%s: error expected", test.name)
+		}
+	}
+}
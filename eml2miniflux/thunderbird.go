@@ -0,0 +1,280 @@
+package eml2miniflux
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/a-ilin/eml2miniflux/util"
+	"miniflux.app/model"
+)
+
+const (
+	thunderbirdPrefsFile  = "prefs.js"
+	thunderbirdServerType = "rss"
+
+	// Prefix of relative paths in prefs.js
+	thunderbirdProfileDir = "[ProfD]"
+
+	// Subfolders of a folder are stored in the directory with this suffix
+	thunderbirdSubfolderSuffix = ".sbd"
+)
+
+var (
+	// ex.: user_pref("mail.server.server2.type", "rss");
+	prefsLineRx = regexp.MustCompile(`^\s*user_pref\(\s*"([^"]+)"\s*,\s*(.*)\);\s*$`)
+
+	// Account folders which do not contain feed items
+	thunderbirdSpecialFolders = map[string]bool{
+		"Trash":           true,
+		"Junk":            true,
+		"Unsent Messages": true,
+		"Drafts":          true,
+		"Templates":       true,
+	}
+)
+
+// ThunderbirdAccount describes a RSS account found in Thunderbird profile
+type ThunderbirdAccount struct {
+	// Key of the server in prefs.js, ex.: server2
+	Key string
+	// Account name as shown by Thunderbird
+	Name string
+	// Directory with the account folders
+	Directory string
+	// Folder files (mbox) or directories (maildir) containing feed items
+	Folders []string
+}
+
+// Load prefs.js of Thunderbird profile as key => value map.
+// String values are unquoted, other values are kept as is.
+func loadThunderbirdPrefs(profilePath string) (map[string]string, error) {
+	file, err := os.Open(filepath.Join(profilePath, thunderbirdPrefsFile))
+	if err != nil {
+		return nil, fmt.Errorf("cannot open profile preferences: %s", err)
+	}
+	defer file.Close()
+
+	prefs := make(map[string]string)
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		match := prefsLineRx.FindStringSubmatch(scanner.Text())
+		if len(match) < 3 {
+			continue
+		}
+
+		value := strings.TrimSpace(match[2])
+		if strings.HasPrefix(value, `"`) {
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+		}
+		prefs[match[1]] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read profile preferences: %s", err)
+	}
+
+	return prefs, nil
+}
+
+// Directory of the account: relative path is preferred, as the profile may be copied from another machine
+func thunderbirdAccountDirectory(profilePath string, prefs map[string]string, serverPrefix string) string {
+	if dir := prefs[serverPrefix+"directory-rel"]; strings.HasPrefix(dir, thunderbirdProfileDir) {
+		return filepath.Join(profilePath, filepath.FromSlash(strings.TrimPrefix(dir, thunderbirdProfileDir)))
+	}
+
+	if dir := prefs[serverPrefix+"directory"]; len(dir) > 0 {
+		if isDir, err := util.IsDirectory(dir); err == nil && isDir {
+			return dir
+		}
+	}
+
+	if hostname := prefs[serverPrefix+"hostname"]; len(hostname) > 0 {
+		return filepath.Join(profilePath, "Mail", hostname)
+	}
+
+	return ""
+}
+
+// ThunderbirdFolderName converts the path of folder file within account directory
+// to the folder name, ex.: `News.sbd/xkcd` => `News/xkcd`
+func ThunderbirdFolderName(accountDir string, folderPath string) string {
+	rel, err := filepath.Rel(accountDir, folderPath)
+	if err != nil {
+		rel = folderPath
+	}
+
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for i := range parts {
+		parts[i] = strings.TrimSuffix(parts[i], thunderbirdSubfolderSuffix)
+	}
+
+	return strings.Join(parts, "/")
+}
+
+// Find folder files (mbox store) and folder directories (maildir store) of the account
+func findThunderbirdFolders(accountDir string) ([]string, error) {
+	var folders []string
+	accountDir = filepath.Clean(accountDir)
+
+	err := filepath.Walk(accountDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "FS Error: %s: %s\n", path, err)
+			return nil
+		}
+
+		if path == accountDir {
+			return nil
+		}
+
+		// the subfolders of a special folder are skipped with it
+		name := strings.TrimSuffix(info.Name(), thunderbirdSubfolderSuffix)
+		if filepath.Dir(path) == accountDir && thunderbirdSpecialFolders[name] {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.IsDir() {
+			if isDir, err := util.IsDirectory(filepath.Join(path, "cur")); err == nil && isDir {
+				folders = append(folders, path)
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		isMbox, err := IsMboxFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "FS Error: %s: %s\n", path, err)
+		} else if isMbox {
+			folders = append(folders, path)
+		}
+
+		return nil
+	})
+
+	return folders, err
+}
+
+// FindThunderbirdAccounts reads prefs.js of the profile and finds RSS accounts with their feed folders
+func FindThunderbirdAccounts(profilePath string) ([]*ThunderbirdAccount, error) {
+	prefs, err := loadThunderbirdPrefs(profilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts []*ThunderbirdAccount
+
+	for key, value := range prefs {
+		if value != thunderbirdServerType || !strings.HasPrefix(key, "mail.server.") || !strings.HasSuffix(key, ".type") {
+			continue
+		}
+
+		serverKey := strings.TrimSuffix(strings.TrimPrefix(key, "mail.server."), ".type")
+		serverPrefix := "mail.server." + serverKey + "."
+
+		account := ThunderbirdAccount{
+			Key:       serverKey,
+			Name:      prefs[serverPrefix+"name"],
+			Directory: thunderbirdAccountDirectory(profilePath, prefs, serverPrefix),
+		}
+
+		if len(account.Directory) == 0 {
+			fmt.Fprintf(os.Stderr, "Cannot find directory of account: %s\n", serverKey)
+			continue
+		}
+
+		account.Folders, err = findThunderbirdFolders(account.Directory)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read account directory: %s: %s", account.Directory, err)
+		}
+
+		accounts = append(accounts, &account)
+	}
+
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Directory < accounts[j].Directory
+	})
+
+	return accounts, nil
+}
+
+// Create entries for all messages of Thunderbird folder
+func (c *entryCollector) addThunderbirdFolder(folderPath string) error {
+	isDir, err := util.IsDirectory(folderPath)
+	if err != nil {
+		return err
+	}
+
+	if isDir {
+		// Maildir store keeps delivered messages under `new` until Thunderbird moves them to `cur`
+		for _, sub := range []string{"cur", "new"} {
+			subPath := filepath.Join(folderPath, sub)
+			if isSubDir, err := util.IsDirectory(subPath); err != nil || !isSubDir {
+				continue
+			}
+
+			err = filepath.Walk(subPath, c.maildirWalkFunc())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return c.addMbox(folderPath)
+}
+
+// Find RSS accounts within Thunderbird profile specified by profilePath,
+// and create model Entry for each message of their folders.
+// Feed subscriptions of the accounts are loaded unless the feed is specified.
+func GetEntriesForProfile(config *EntryConfig, profilePath string) (model.Entries, error) {
+	c := newEntryCollector(config)
+
+	accounts, err := FindThunderbirdAccounts(profilePath)
+	if err != nil {
+		return c.entries, err
+	}
+
+	if len(accounts) == 0 {
+		return c.entries, fmt.Errorf("no RSS accounts found in profile: %s", profilePath)
+	}
+
+	for _, account := range accounts {
+		fmt.Fprintf(os.Stdout, "Found RSS account '%s': %s\n", account.Name, account.Directory)
+		for _, folder := range account.Folders {
+			fmt.Fprintf(os.Stdout, "  Folder: %s\n", ThunderbirdFolderName(account.Directory, folder))
+		}
+	}
+
+	if config.DefaultFeed == nil {
+		err = config.FeedHelper.LoadProfileSubscriptions(accounts)
+		if err != nil {
+			return c.entries, fmt.Errorf("cannot load profile subscriptions: %s", err)
+		}
+	}
+
+	for _, account := range accounts {
+		c.root = account.Directory
+		for _, folder := range account.Folders {
+			err = c.addThunderbirdFolder(folder)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error on processing folder: %s: %s\n", folder, err)
+			}
+		}
+	}
+
+	fmt.Fprintf(os.Stdout, "Reading profile completed. Processed messages: %d\n", c.entryCounter)
+
+	return c.entries, nil
+}
@@ -0,0 +1,133 @@
+package eml2miniflux
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Create files of the profile by path relative to it; directories end with '/'
+func writeTestProfile(t *testing.T, profile string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(profile, filepath.FromSlash(name))
+		if strings.HasSuffix(name, "/") {
+			if err := os.MkdirAll(path, 0777); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFindThunderbirdAccounts(t *testing.T) {
+	profile := t.TempDir()
+	mbox := "From - Wed May 11 14:31:59 2016\nSubject: Post\n\nText\n"
+	absoluteDir := filepath.Join(t.TempDir(), "Old Feeds")
+
+	prefs := strings.Join([]string{
+		"// Mozilla User Preferences",
+		`user_pref("mail.server.server1.type", "imap");`,
+		`user_pref("mail.server.server1.directory-rel", "[ProfD]ImapMail/imap.example.com");`,
+		// relative directory is preferred to the absolute one of another machine
+		`user_pref("mail.server.server2.type", "rss");`,
+		`user_pref("mail.server.server2.name", "Blogs & News Feeds");`,
+		`user_pref("mail.server.server2.directory", "C:\\Users\\john\\Feeds");`,
+		`user_pref("mail.server.server2.directory-rel", "[ProfD]Mail/Feeds");`,
+		`user_pref("mail.server.server3.type", "rss");`,
+		fmt.Sprintf(`user_pref("mail.server.server3.directory", %s);`, strconv.Quote(absoluteDir)),
+		`  user_pref("mail.server.server3.name", "Old \"Feeds\"");`,
+		`user_pref("mail.server.server4.type", "rss");`,
+		`user_pref("mail.server.server4.hostname", "Feeds-2");`,
+		`user_pref("mail.server.server5.type", "rss");`,
+		`user_pref("mail.server.server5.name", "Without directory");`,
+	}, "\n")
+
+	writeTestProfile(t, profile, map[string]string{
+		"prefs.js":                        prefs,
+		"ImapMail/imap.example.com/INBOX": mbox,
+		"Mail/Feeds/Go":                   mbox,
+		"Mail/Feeds/Go.msf":               "// <!-- <mdb:mork:z v=\"1.4\"/> -->",
+		"Mail/Feeds/feeds.json":           "[]",
+		"Mail/Feeds/Empty":                "",
+		"Mail/Feeds/News":                 mbox,
+		"Mail/Feeds/News.sbd/xkcd":        mbox,
+		"Mail/Feeds/News.sbd/Trash":       mbox,
+		"Mail/Feeds/Trash":                mbox,
+		"Mail/Feeds/Junk":                 mbox,
+		"Mail/Feeds/Trash.sbd/Old":        mbox,
+		"Mail/Feeds/Drafts/cur/":          "",
+		"Mail/Feeds/Blogs/cur/1.eml":      "Subject: Post\n\nText\n",
+		"Mail/Feeds/Blogs/new/":           "",
+		"Mail/Feeds-2/Comics":             mbox,
+	})
+	writeTestProfile(t, absoluteDir, map[string]string{"Archive": mbox})
+
+	accounts, err := FindThunderbirdAccounts(profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type account struct {
+		key     string
+		name    string
+		dir     string
+		folders []string
+	}
+	want := []account{
+		{key: "server2", name: "Blogs & News Feeds", dir: filepath.Join(profile, "Mail", "Feeds"), folders: []string{"Blogs", "Go", "News", "News/Trash", "News/xkcd"}},
+		{key: "server4", dir: filepath.Join(profile, "Mail", "Feeds-2"), folders: []string{"Comics"}},
+		{key: "server3", name: `Old "Feeds"`, dir: absoluteDir, folders: []string{"Archive"}},
+	}
+	// accounts are sorted by directory
+	if absoluteDir < want[0].dir {
+		want = append(want[2:], want[:2]...)
+	}
+
+	if len(accounts) != len(want) {
+		t.Fatalf("got %d accounts, want %d", len(accounts), len(want))
+	}
+	for i, w := range want {
+		got := accounts[i]
+		var folders []string
+		for _, folder := range got.Folders {
+			folders = append(folders, ThunderbirdFolderName(got.Directory, folder))
+		}
+		if got.Key != w.key || got.Name != w.name || got.Directory != w.dir || strings.Join(folders, ",") != strings.Join(w.folders, ",") {
+			t.Errorf("account #%d: got %s %q %s %v, want %s %q %s %v", i+1, got.Key, got.Name, got.Directory, folders, w.key, w.name, w.dir, w.folders)
+		}
+	}
+}
+
+func TestThunderbirdFolderName(t *testing.T) {
+	accountDir := filepath.Join("profile", "Mail", "Feeds")
+	tests := []struct {
+		folder string
+		want   string
+	}{
+		{folder: "Go", want: "Go"},
+		{folder: "News.sbd/xkcd", want: "News/xkcd"},
+		{folder: "News.sbd/Comics.sbd/xkcd", want: "News/Comics/xkcd"},
+		{folder: "Go.sbd", want: "Go"},
+	}
+
+	for _, test := range tests {
+		if got := ThunderbirdFolderName(accountDir, filepath.Join(accountDir, filepath.FromSlash(test.folder))); got != test.want {
+			t.Errorf("ThunderbirdFolderName(%q) = %q, want %q", test.folder, got, test.want)
+		}
+	}
+}
+
+func TestFindThunderbirdAccountsWithoutPrefs(t *testing.T) {
+	if _, err := FindThunderbirdAccounts(t.TempDir()); err == nil {
+		t.Errorf("error expected for profile without prefs.js")
+	}
+}
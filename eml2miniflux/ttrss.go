@@ -0,0 +1,158 @@
+package eml2miniflux
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"miniflux.app/model"
+)
+
+const (
+	ttrssRootElement    = "articles"
+	ttrssArticleElement = "article"
+)
+
+var (
+	// TT-RSS writes dates in the time zone of the server, which is not exported
+	ttrssDateLayouts = []string{
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04:05-07",
+		"2006-01-02T15:04:05Z07:00",
+	}
+)
+
+// Article of Tiny Tiny RSS export, as written by its import/export plugin
+type ttrssArticle struct {
+	Guid       string `xml:"guid"`
+	Title      string `xml:"title"`
+	Content    string `xml:"content"`
+	Marked     string `xml:"marked"`
+	Link       string `xml:"link"`
+	TagCache   string `xml:"tag_cache"`
+	LabelCache string `xml:"label_cache"`
+	FeedTitle  string `xml:"feed_title"`
+	FeedUrl    string `xml:"feed_url"`
+	Updated    string `xml:"updated"`
+}
+
+// Find the name of the root element of XML file; empty string is returned when it cannot be read
+func xmlRootElement(r io.Reader) string {
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local
+		}
+	}
+}
+
+// IsTinyTinyRSSExport determines if a file represented by `filePath`
+// is Tiny Tiny RSS export, i.e. XML file with `articles` root element
+func IsTinyTinyRSSExport(filePath string) bool {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	return xmlRootElement(bufio.NewReader(file)) == ttrssRootElement
+}
+
+// Captions of TT-RSS labels, ex.: `[[-1025,"Important","#fff","#f00"]]`
+func parseTTRSSLabels(labelCache string) []string {
+	var labels [][]any
+	if json.Unmarshal([]byte(labelCache), &labels) != nil {
+		return nil
+	}
+
+	var captions []string
+	for _, label := range labels {
+		if len(label) >= 2 {
+			if caption, ok := label[1].(string); ok {
+				captions = append(captions, caption)
+			}
+		}
+	}
+
+	return captions
+}
+
+func (article *ttrssArticle) feedReaderItem() *feedReaderItem {
+	item := &feedReaderItem{
+		title:     strings.TrimSpace(article.Title),
+		url:       strings.TrimSpace(article.Link),
+		feedUrl:   strings.TrimSpace(article.FeedUrl),
+		content:   article.Content,
+		starred:   strings.TrimSpace(article.Marked) == "1",
+		published: parseTimeLayouts(strings.TrimSpace(article.Updated), ttrssDateLayouts),
+		// Export does not keep read state of the articles
+		unknownState: true,
+	}
+
+	// TT-RSS keeps its own hash of the GUID, so it is used only when the link is missing
+	if len(item.url) == 0 {
+		item.guid = strings.TrimSpace(article.Guid)
+	}
+
+	for _, tag := range strings.Split(article.TagCache, ",") {
+		item.tags = append(item.tags, strings.TrimSpace(tag))
+	}
+	item.tags = append(item.tags, parseTTRSSLabels(article.LabelCache)...)
+
+	return item
+}
+
+// Read articles of TT-RSS export one by one
+func loadTTRSSItems(r io.Reader, fn func(item *feedReaderItem) error) error {
+	decoder := xml.NewDecoder(r)
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("cannot read XML: %s", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != ttrssArticleElement {
+			continue
+		}
+
+		var article ttrssArticle
+		if err = decoder.DecodeElement(&article, &start); err != nil {
+			return fmt.Errorf("cannot unmarshal article: %s", err)
+		}
+
+		if err = fn(article.feedReaderItem()); err != nil {
+			return err
+		}
+	}
+}
+
+// Load articles from TT-RSS export stream `r` and create model Entry for each of them;
+// `source` names the stream in error messages
+func GetEntriesForTTRSSReader(config *EntryConfig, source string, r io.Reader) (model.Entries, error) {
+	return getEntriesForFeedReader(config, "TT-RSS export", source, func(fn func(item *feedReaderItem) error) error {
+		return loadTTRSSItems(r, fn)
+	})
+}
+
+// Load articles from TT-RSS export file specified by filePath and create model Entry for each of them
+func GetEntriesForTTRSS(config *EntryConfig, filePath string) (model.Entries, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return model.Entries{}, fmt.Errorf("cannot open file: %s", err)
+	}
+	defer file.Close()
+
+	return GetEntriesForTTRSSReader(config, filePath, bufio.NewReader(file))
+}
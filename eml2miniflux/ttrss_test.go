@@ -0,0 +1,139 @@
+package eml2miniflux
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"miniflux.app/crypto"
+	"miniflux.app/model"
+)
+
+// Export of TT-RSS import/export plugin
+const testTTRSSExport = `<?xml version="1.0" encoding="utf-8"?>
+<articles schema-version="137">
+<article>
+	<guid><![CDATA[SHA1:6c2bd0b8d9b7b8a43b7f3fbd1d3e9e5b2f0c1a2d]]></guid>
+	<title><![CDATA[Go 1.20 is released]]></title>
+	<content><![CDATA[<p>Go 1.20 is out.</p>]]></content>
+	<marked><![CDATA[1]]></marked>
+	<published><![CDATA[0]]></published>
+	<score><![CDATA[0]]></score>
+	<note><![CDATA[]]></note>
+	<link><![CDATA[ https://go.dev/blog/go1.20 ]]></link>
+	<tag_cache><![CDATA[go, release]]></tag_cache>
+	<label_cache><![CDATA[[[-1025,"Important","#fff","#f00"]]]]></label_cache>
+	<feed_title><![CDATA[The Go Blog]]></feed_title>
+	<feed_url><![CDATA[https://go.dev/blog/feed.atom]]></feed_url>
+	<updated><![CDATA[2023-01-02 03:04:05]]></updated>
+</article>
+<article>
+	<guid><![CDATA[{"ver":2,"uid":"1","hash":"SHA1:0123"}]]></guid>
+	<title><![CDATA[Without link]]></title>
+	<content><![CDATA[<p>Text</p>]]></content>
+	<marked><![CDATA[0]]></marked>
+	<link><![CDATA[]]></link>
+	<tag_cache><![CDATA[]]></tag_cache>
+	<label_cache><![CDATA[]]></label_cache>
+	<feed_url><![CDATA[https://blog.example.com/rss]]></feed_url>
+	<updated><![CDATA[2023-01-03T04:05:06+02:00]]></updated>
+</article>
+<article>
+	<title><![CDATA[Unknown feed]]></title>
+	<link><![CDATA[https://unknown.example.org/post]]></link>
+	<feed_url><![CDATA[https://unknown.example.org/feed]]></feed_url>
+	<updated><![CDATA[2023-01-04 05:06:07+03]]></updated>
+</article>
+</articles>
+`
+
+func TestGetEntriesForTTRSSReader(t *testing.T) {
+	goFeed := &model.Feed{FeedURL: "https://go.dev/blog/feed.atom"}
+	exampleFeed := &model.Feed{FeedURL: "https://blog.example.com/rss"}
+	feedHelper := newTestFeedHelper(t, "", goFeed, exampleFeed)
+
+	tests := []struct {
+		url     string
+		hash    string
+		feed    *model.Feed
+		starred bool
+		tags    string
+		date    time.Time
+	}{
+		{
+			url:     "https://go.dev/blog/go1.20",
+			hash:    crypto.Hash("https://go.dev/blog/go1.20"),
+			feed:    goFeed,
+			starred: true,
+			tags:    "go release Important",
+			date:    time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
+		{
+			url:  "",
+			hash: crypto.Hash(`{"ver":2,"uid":"1","hash":"SHA1:0123"}`),
+			feed: exampleFeed,
+			date: time.Date(2023, 1, 3, 2, 5, 6, 0, time.UTC),
+		},
+	}
+
+	// The export does not keep read state, so the default status applies
+	for _, status := range []string{"", model.EntryStatusRead} {
+		config := &EntryConfig{FeedHelper: feedHelper, User: &model.User{ID: 1}, Quiet: true, DefaultStatus: status}
+		wantStatus := status
+		if len(wantStatus) == 0 {
+			wantStatus = model.EntryStatusUnread
+		}
+
+		entries, err := GetEntriesForTTRSSReader(config, "export", strings.NewReader(testTTRSSExport))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != len(tests) {
+			t.Fatalf("got %d entries, want %d", len(entries), len(tests))
+		}
+
+		for i, test := range tests {
+			entry := entries[i]
+			if entry.URL != test.url || entry.Hash != test.hash {
+				t.Errorf("article #%d: got URL %q, hash %s", i+1, entry.URL, entry.Hash)
+			}
+			if entry.FeedID != test.feed.ID {
+				t.Errorf("article #%d: got feed %d, want %d", i+1, entry.FeedID, test.feed.ID)
+			}
+			if entry.Status != wantStatus || entry.Starred != test.starred {
+				t.Errorf("article #%d: got status %s, starred %v, want %s, %v", i+1, entry.Status, entry.Starred, wantStatus, test.starred)
+			}
+			if tags := strings.Join(entry.Tags, " "); tags != test.tags {
+				t.Errorf("article #%d: got tags %q, want %q", i+1, tags, test.tags)
+			}
+			if !entry.Date.Equal(test.date) {
+				t.Errorf("article #%d: got date %s, want %s", i+1, entry.Date, test.date)
+			}
+		}
+	}
+}
+
+func TestIsTinyTinyRSSExport(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{name: "export.xml", content: testTTRSSExport, want: true},
+		{name: "feed.xml", content: `<?xml version="1.0"?><rss version="2.0"><channel></channel></rss>`},
+		{name: "broken.xml", content: `<?xml version="1.0"?>`},
+	}
+
+	for _, test := range tests {
+		path := filepath.Join(dir, test.name)
+		if err := os.WriteFile(path, []byte(test.content), 0666); err != nil {
+			t.Fatal(err)
+		}
+		if got := IsTinyTinyRSSExport(path); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package eml2miniflux
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/sg3des/eml"
+)
+
+const (
+	// Placeholders of the article URL template
+	usenetMessageIdPlaceholder = "{messageid}"
+	usenetGroupPlaceholder     = "{group}"
+)
+
+// Newsgroups of Usenet article, ex.: `Newsgroups: comp.lang.go,comp.misc`; empty for a mail message
+func usenetNewsgroups(message *eml.Message) []string {
+	var groups []string
+	for _, group := range strings.Split(messageHeader(message, "Newsgroups"), ",") {
+		group = strings.TrimSpace(group)
+		if len(group) > 0 {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// URL of Usenet article by its Message-ID: `news:` URL (RFC 5538),
+// or the archive URL when the template is given
+func usenetArticleUrl(messageId string, group string, urlTemplate string) string {
+	messageId = strings.Trim(messageId, "<> ")
+	if len(messageId) == 0 {
+		return ""
+	}
+
+	if len(urlTemplate) == 0 {
+		return "news:" + messageId
+	}
+
+	return strings.NewReplacer(
+		usenetMessageIdPlaceholder, url.PathEscape(messageId),
+		usenetGroupPlaceholder, url.PathEscape(group),
+	).Replace(urlTemplate)
+}
@@ -0,0 +1,109 @@
+package eml2miniflux
+
+import (
+	"strings"
+	"testing"
+
+	"miniflux.app/model"
+)
+
+func TestUsenetArticleUrl(t *testing.T) {
+	tests := []struct {
+		name        string
+		messageId   string
+		group       string
+		urlTemplate string
+		want        string
+	}{
+		{name: "news URL", messageId: "<abc.123@news.example.com>", group: "comp.lang.go", want: "news:abc.123@news.example.com"},
+		{name: "message ID without brackets", messageId: "abc.123@news.example.com", want: "news:abc.123@news.example.com"},
+		{name: "empty message ID", messageId: " <> ", urlTemplate: "https://archive.example.com/{messageid}"},
+		{
+			name:        "archive URL",
+			messageId:   "<abc/123@news.example.com>",
+			group:       "comp.lang.go",
+			urlTemplate: "https://archive.example.com/{group}/{messageid}",
+			want:        "https://archive.example.com/comp.lang.go/abc%2F123@news.example.com",
+		},
+		{
+			name:        "template without group",
+			messageId:   "<abc 123@news.example.com>",
+			group:       "comp.lang.go",
+			urlTemplate: "https://archive.example.com/article/{messageid}",
+			want:        "https://archive.example.com/article/abc%20123@news.example.com",
+		},
+	}
+
+	for _, test := range tests {
+		if got := usenetArticleUrl(test.messageId, test.group, test.urlTemplate); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestUsenetNewsgroups(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []string
+		want    string
+	}{
+		{name: "single group", headers: []string{"Newsgroups: comp.lang.go"}, want: "comp.lang.go"},
+		{name: "multiple groups", headers: []string{"Newsgroups: comp.lang.go, comp.misc,,alt.test "}, want: "comp.lang.go comp.misc alt.test"},
+		{name: "mail message"},
+	}
+
+	for _, test := range tests {
+		message := testHtmlMessage(t, test.headers, "<p>Text</p>")
+		if got := strings.Join(usenetNewsgroups(message), " "); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestCreateEntryForUsenetArticle(t *testing.T) {
+	goFeed := &model.Feed{FeedURL: "https://example.com/comp.lang.go.xml"}
+	miscFeed := &model.Feed{FeedURL: "https://example.com/comp.misc.xml"}
+	feedHelper := newTestFeedHelper(t, "comp.misc => https://example.com/comp.misc.xml\n", goFeed, miscFeed)
+
+	message := testHtmlMessage(t, []string{
+		"Newsgroups: comp.lang.go,comp.misc",
+		"Message-ID: <reply.2@news.example.com>",
+		"References: <thread.1@news.example.com> <reply.1@news.example.com>",
+	}, "<p>Text</p>")
+
+	tests := []struct {
+		name        string
+		urlTemplate string
+		url         string
+		commentsUrl string
+	}{
+		{
+			name:        "news URL",
+			url:         "news:reply.2@news.example.com",
+			commentsUrl: "news:thread.1@news.example.com",
+		},
+		{
+			name:        "archive URL by the first group",
+			urlTemplate: "https://archive.example.com/{group}/{messageid}",
+			url:         "https://archive.example.com/comp.lang.go/reply.2@news.example.com",
+			commentsUrl: "https://archive.example.com/comp.lang.go/thread.1@news.example.com",
+		},
+	}
+
+	for _, test := range tests {
+		config := &EntryConfig{FeedHelper: feedHelper, User: &model.User{ID: 1}, Quiet: true, NewsUrlTemplate: test.urlTemplate}
+
+		entry, err := CreateEntryForEML(message, nil, config)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		if entry.URL != test.url || entry.CommentsURL != test.commentsUrl {
+			t.Errorf("%s: got URL %q, comments URL %q", test.name, entry.URL, entry.CommentsURL)
+		}
+		// the first group is unknown, so the article goes to the feed of the next one
+		if entry.FeedID != miscFeed.ID {
+			t.Errorf("%s: got feed %d, want %d", test.name, entry.FeedID, miscFeed.ID)
+		}
+	}
+}
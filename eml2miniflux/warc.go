@@ -0,0 +1,278 @@
+package eml2miniflux
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html/charset"
+	"miniflux.app/crypto"
+	"miniflux.app/model"
+	"miniflux.app/reader/readability"
+)
+
+var (
+	// Web archive files by file name suffix; each record of `.warc.gz` is a separate gzip member
+	warcSuffixes = []string{".warc", ".warc.gz"}
+
+	// Elements holding the article, in order of preference
+	warcArticleSelectors = []string{"article", "main", "[role=main]"}
+)
+
+// Record of a web archive: WARC headers and the content block
+type warcRecord struct {
+	header textproto.MIMEHeader
+	block  io.Reader
+}
+
+// IsWarcFile determines if a file represented by `filePath`
+// is a web archive (optionally compressed by gzip) by its name
+func IsWarcFile(filePath string) bool {
+	name := strings.ToLower(filePath)
+	for _, suffix := range warcSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Read the next record of web archive; the block must be read or discarded before the next call
+func readWarcRecord(r *bufio.Reader) (*warcRecord, error) {
+	tp := textproto.NewReader(r)
+
+	// Records are separated by empty lines
+	var version string
+	for len(version) == 0 {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+		version = strings.TrimSpace(line)
+	}
+	if !strings.HasPrefix(version, "WARC/") {
+		return nil, fmt.Errorf("unexpected record version: %s", version)
+	}
+
+	header, err := tp.ReadMIMEHeader()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read record header: %s", err)
+	}
+
+	length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("wrong record length: %s", err)
+	}
+
+	return &warcRecord{header: header, block: io.LimitReader(r, length)}, nil
+}
+
+// URL of the archived page; WARC 0.x writers put it into angle brackets
+func (record *warcRecord) targetUrl() string {
+	return strings.Trim(record.header.Get("WARC-Target-URI"), "<>")
+}
+
+// Response of the record with HTML page, nil when the record holds anything else
+func (record *warcRecord) htmlResponse() *http.Response {
+	if record.header.Get("WARC-Type") != "response" {
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(record.header.Get("Content-Type"))
+	if mediaType != "application/http" {
+		return nil
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(record.block), nil)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	mediaType, _, _ = mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil
+	}
+
+	return resp
+}
+
+// Parse HTML page of the response, decoding its transfer encoding and charset
+func parseWarcPage(resp *http.Response) (*goquery.Document, error) {
+	var body io.Reader = resp.Body
+
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		gzipReader, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decompress page: %s", err)
+		}
+		defer gzipReader.Close()
+		body = gzipReader
+	}
+
+	body, err := charset.NewReader(body, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode page: %s", err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(body)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse page: %s", err)
+	}
+
+	return doc, nil
+}
+
+// Content of the main article element of the page; readability is used when the page has none
+func extractWarcArticle(doc *goquery.Document) (string, error) {
+	for _, selector := range warcArticleSelectors {
+		selection := doc.Find(selector).First()
+		if selection.Length() > 0 {
+			return selection.Html()
+		}
+	}
+
+	page, err := doc.Html()
+	if err != nil {
+		return "", err
+	}
+
+	return readability.ExtractContent(strings.NewReader(page))
+}
+
+// Determine if the page URL is within the site of the feed: the host and path prefix of its site URL,
+// or the host of its feed URL when the site URL is missing
+func isFeedSitePage(feed *model.Feed, pageUrl string) bool {
+	host, path := siteHostPath(feed.SiteURL)
+	if len(host) == 0 {
+		host, _ = siteHostPath(feed.FeedURL)
+		path = ""
+	}
+
+	pageHost, pagePath := siteHostPath(pageUrl)
+	if len(host) == 0 || pageHost != host {
+		return false
+	}
+
+	return len(path) == 0 || pagePath == path || strings.HasPrefix(pagePath, path+"/")
+}
+
+// Create entry for the HTML page of the record. Error is returned when the page does not match any feed.
+func createEntryForWarcRecord(record *warcRecord, resp *http.Response, config *EntryConfig) (*model.Entry, error) {
+	entry := model.Entry{
+		Status:     model.EntryStatusUnread,
+		URL:        record.targetUrl(),
+		Enclosures: make(model.EnclosureList, 0),
+	}
+
+	if len(config.DefaultStatus) > 0 {
+		entry.Status = config.DefaultStatus
+	}
+
+	// Match before parsing, as most pages of a site archive belong to no feed;
+	// the default feed takes only the pages of its site, not the whole archive
+	if config.DefaultFeed != nil && !isFeedSitePage(config.DefaultFeed, entry.URL) {
+		return nil, &FeedNoMatchError{entryUrl: entry.URL}
+	}
+
+	feed, err := assignUserFeed(&entry, nil, nil, nil, config.User, config.FeedHelper, config.DefaultFeed)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Hash = crypto.Hash(entry.URL)
+
+	entry.Date, err = time.Parse(time.RFC3339, record.header.Get("WARC-Date"))
+	if err != nil {
+		entry.Date = time.Now()
+	}
+	entry.CreatedAt = entry.Date
+	entry.ChangedAt = entry.Date
+
+	doc, err := parseWarcPage(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Title = strings.TrimSpace(doc.Find("title").First().Text())
+	if len(entry.Title) == 0 {
+		entry.Title = entry.URL
+	}
+
+	entry.Content, err = extractWarcArticle(doc)
+	if err != nil {
+		return nil, fmt.Errorf("cannot extract article: %s", err)
+	}
+
+	rewriteEntry(&entry, config.User, feed)
+
+	return &entry, nil
+}
+
+// Load HTML pages from web archive stream `r` and create model Entry for each page matching a feed;
+// `source` names the stream in error messages
+func GetEntriesForWarcReader(config *EntryConfig, source string, r io.Reader) (model.Entries, error) {
+	c := newEntryCollector(config)
+	unmatched := 0
+
+	br := bufio.NewReader(r)
+	for {
+		record, err := readWarcRecord(br)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return c.entries, fmt.Errorf("cannot read web archive: %s", err)
+		}
+
+		if resp := record.htmlResponse(); resp != nil {
+			c.countMessage()
+
+			entry, err := createEntryForWarcRecord(record, resp, config)
+			if _, ok := err.(*FeedNoMatchError); ok {
+				// not an article of the subscribed feeds
+				unmatched++
+			} else if err != nil {
+				reportEntryError(fmt.Sprintf("%s: %s", source, record.targetUrl()), err, config.Quiet)
+			} else {
+				c.entries = append(c.entries, entry)
+			}
+		}
+
+		if _, err = io.Copy(io.Discard, record.block); err != nil {
+			return c.entries, fmt.Errorf("cannot read web archive: %s", err)
+		}
+	}
+
+	fmt.Fprintf(os.Stdout, "Reading WARC completed. Processed pages: %d, not matching any feed: %d\n", c.entryCounter, unmatched)
+
+	return c.entries, nil
+}
+
+// Load HTML pages from web archive specified by filePath and create model Entry for each page matching a feed
+func GetEntriesForWarc(config *EntryConfig, filePath string) (model.Entries, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return model.Entries{}, fmt.Errorf("cannot open file: %s", err)
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(strings.ToLower(filePath), ".gz") {
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			return model.Entries{}, fmt.Errorf("cannot decompress file: %s", err)
+		}
+		defer gzipReader.Close()
+		r = gzipReader
+	}
+
+	return GetEntriesForWarcReader(config, filePath, r)
+}
@@ -0,0 +1,70 @@
+package eml2miniflux
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"miniflux.app/model"
+)
+
+// Build WARC response record of HTML page
+func testWarcRecord(url string, title string) string {
+	page := fmt.Sprintf("<html><head><title>%s</title></head><body><article><p>Text of %s</p></article></body></html>", title, title)
+	block := fmt.Sprintf("HTTP/1.1 200 OK\r\nContent-Type: text/html\r\nContent-Length: %d\r\n\r\n%s", len(page), page)
+	return fmt.Sprintf("WARC/1.0\r\nWARC-Type: response\r\nWARC-Target-URI: <%s>\r\nWARC-Date: 2020-01-02T03:04:05Z\r\n"+
+		"Content-Type: application/http; msgtype=response\r\nContent-Length: %d\r\n\r\n%s\r\n\r\n", url, len(block), block)
+}
+
+func TestIsFeedSitePage(t *testing.T) {
+	tests := []struct {
+		siteUrl string
+		feedUrl string
+		pageUrl string
+		want    bool
+	}{
+		{"https://blog.example.com/", "", "https://blog.example.com/posts/1", true},
+		{"https://blog.example.com/", "", "http://www.blog.example.com/posts/1", true},
+		{"https://blog.example.com/", "", "https://example.com/posts/1", false},
+		{"https://blog.example.com/", "", "https://cdn.blog.example.com/posts/1", false},
+		{"https://example.com/blog/", "", "https://example.com/blog/post", true},
+		{"https://example.com/blog/", "", "https://example.com/blog", true},
+		{"https://example.com/blog/", "", "https://example.com/blogroll", false},
+		{"https://example.com/blog/", "", "https://example.com/shop/item", false},
+		{"", "https://example.com/feeds/all.xml", "https://example.com/shop/item", true},
+		{"", "", "https://example.com/shop/item", false},
+	}
+
+	for _, test := range tests {
+		feed := &model.Feed{SiteURL: test.siteUrl, FeedURL: test.feedUrl}
+		if got := isFeedSitePage(feed, test.pageUrl); got != test.want {
+			t.Errorf("isFeedSitePage(%q, %q, %q) = %v, want %v", test.siteUrl, test.feedUrl, test.pageUrl, got, test.want)
+		}
+	}
+}
+
+func TestGetEntriesForWarcReaderDefaultFeed(t *testing.T) {
+	archive := testWarcRecord("https://example.com/blog/first", "First") +
+		testWarcRecord("https://example.com/shop/item", "Item") +
+		testWarcRecord("https://other.example.org/page", "Other")
+
+	feed := &model.Feed{ID: 1, SiteURL: "https://example.com/blog/", FeedURL: "https://example.com/blog/feed.xml"}
+	config := &EntryConfig{
+		FeedHelper:  newTestFeedHelper(t, "", feed),
+		User:        &model.User{ID: 1},
+		DefaultFeed: feed,
+		Quiet:       true,
+	}
+
+	entries, err := GetEntriesForWarcReader(config, "test.warc", strings.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	if entries[0].URL != "https://example.com/blog/first" || entries[0].Title != "First" || entries[0].FeedID != feed.ID {
+		t.Errorf("got entry %q %q of feed %d", entries[0].URL, entries[0].Title, entries[0].FeedID)
+	}
+}
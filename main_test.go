@@ -0,0 +1,117 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Data compressed by gzip
+func gzipData(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// Data compressed by zstd
+func zstdData(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	writer, err := zstd.NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// Tar archive holding the file
+func tarData(t *testing.T, name string, data []byte) []byte {
+	var buf bytes.Buffer
+	archive := tar.NewWriter(&buf)
+	if err := archive.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := archive.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestStdinMessageType(t *testing.T) {
+	message := []byte("From: Blog <blog@example.com>\r\nSubject: Post\r\n\r\nText\r\n")
+	mbox := []byte("From blog@example.com Wed May 11 14:31:59 2016\nSubject: Post\n\nText\n")
+	warc := []byte("WARC/1.0\r\nWARC-Type: warcinfo\r\nContent-Length: 0\r\n\r\n\r\n\r\n")
+	archive := tarData(t, "blog/post.eml", message)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		plain   []byte
+		want    int
+		wantErr string
+	}{
+		{name: "message", data: message, want: MESSAGE_EML},
+		{name: "mbox", data: mbox, want: MESSAGE_MBOX},
+		{name: "JSON dump", data: []byte("\n[{\"id\": 1}]"), want: MESSAGE_JSON},
+		{name: "Google Reader JSON", data: []byte(`{"items": []}`), want: MESSAGE_GREADER},
+		{name: "TT-RSS export", data: []byte(`<?xml version="1.0"?><articles schema-version="137"></articles>`), want: MESSAGE_TTRSS},
+		{name: "feed", data: []byte(`<?xml version="1.0"?><rss version="2.0"></rss>`), want: MESSAGE_FEED},
+		{name: "web archive", data: warc, want: MESSAGE_WARC},
+		{name: "tar", data: archive, want: MESSAGE_ARCHIVE},
+		{name: "tar.gz", data: gzipData(t, archive), plain: archive, want: MESSAGE_ARCHIVE},
+		{name: "tar.zst", data: zstdData(t, archive), plain: archive, want: MESSAGE_ARCHIVE},
+		{name: "warc.gz", data: gzipData(t, warc), plain: warc, want: MESSAGE_WARC},
+		{name: "mbox.gz", data: gzipData(t, mbox), plain: mbox, want: MESSAGE_MBOX},
+		{name: "mbox.zst", data: zstdData(t, mbox), plain: mbox, want: MESSAGE_MBOX},
+		{name: "zip", data: []byte("PK\x03\x04\x14\x00"), wantErr: "zip archive"},
+		{name: "empty", data: []byte(" \r\n"), wantErr: "standard input is empty"},
+		{name: "empty gzip", data: gzipData(t, nil), wantErr: "standard input is empty"},
+		{name: "broken gzip", data: []byte{0x1f, 0x8b, 0x00}, wantErr: "unable to read standard input"},
+	}
+
+	for _, test := range tests {
+		messageType, r, err := stdinMessageType(bufio.NewReader(bytes.NewReader(test.data)))
+		if len(test.wantErr) > 0 {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("%s: got error %v, want %q", test.name, err, test.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		if messageType != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, messageTypeName(messageType), messageTypeName(test.want))
+		}
+
+		// the returned reader gives the decompressed content from its beginning
+		want := test.data
+		if test.plain != nil {
+			want = test.plain
+		}
+		if content, err := io.ReadAll(r); err != nil || !bytes.Equal(content, want) {
+			t.Errorf("%s: got content %q, error %v", test.name, content, err)
+		}
+	}
+}